@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var addLink bool
+
+var addCmd = &cobra.Command{
+	Use:   "add [type/entry]...",
+	Short: "Install individual entries",
+	Long:  "Installs individual entries (e.g. rules/terraform commands/get-conflict-responsible) into the current directory's .cursor/ folder without a collection.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, ref := range args {
+			if _, _, err := collection.SplitRef(ref); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		if addLink && !source.IsLocal(sourceFlag) {
+			fmt.Fprintln(os.Stderr, "Error: --link requires --source to be a local checkout path")
+			os.Exit(1)
+		}
+
+		src := source.Open(sourceFlag, refFlag)
+
+		cf, err := fetchCollectionFile(src)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		forEachCursorDir(func(dir string) error {
+			inst, err := newInstaller(src, dir)
+			if err != nil {
+				return err
+			}
+			defer inst.Close()
+			if err := snapshotBefore(inst, dir); err != nil {
+				return err
+			}
+			inst.SetLink(addLink)
+			inst.SetConflictMode(conflictMode())
+			return inst.Add(cf, args)
+		})
+	},
+}
+
+func init() {
+	addCmd.Flags().BoolVar(&addLink, "link", false, "Symlink entries from a local --source instead of copying them")
+	addConflictFlags(addCmd)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var adoptForce bool
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [type/entry]...",
+	Short: "Let curset manage entries that already exist locally",
+	Long:  "Records existing, unmanaged entries of the .cursor/ folder (e.g. rules/go) in the manifest so that future installs update them. An entry is adopted only if it is identical to the source's; --force adopts it anyway, and the next update replaces the local changes. Without arguments, every unmanaged entry is considered, and those the source doesn't have, such as your own rules, are skipped.",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, ref := range args {
+			if _, _, err := collection.SplitRef(ref); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		src := source.Open(sourceFlag, refFlag)
+
+		cf, err := fetchCollectionFile(src)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		forEachCursorDir(func(dir string) error {
+			inst, err := newInstaller(src, dir)
+			if err != nil {
+				return err
+			}
+			defer inst.Close()
+			if err := snapshotBefore(inst, dir); err != nil {
+				return err
+			}
+			return inst.Adopt(cf, args, adoptForce)
+		})
+	},
+}
+
+func init() {
+	adoptCmd.Flags().BoolVar(&adoptForce, "force", false, "Adopt entries even if their files differ from the source")
+}
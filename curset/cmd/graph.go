@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var graphFormat string
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Print the collection and dependency graph",
+	Long: `Fetches the collection.json and prints which entries each collection lists and which
+entries depend on which, as Graphviz DOT (default) or a Mermaid flowchart. Dependency
+edges are dashed. For example:
+
+  curset graph | dot -Tsvg > collections.svg
+  curset graph --format mermaid >> docs/rules.md`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := collection.CheckGraphFormat(graphFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		src := source.Open(sourceFlag, refFlag)
+
+		cf, err := fetchCollectionFile(src)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		out, err := cf.Graph(graphFormat)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(out)
+	},
+}
+
+func init() {
+	graphCmd.Flags().StringVar(&graphFormat, "format", collection.GraphDOT, "Output format: dot or mermaid")
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [type/name]",
+	Short: "Show the install history of the .cursor folder",
+	Long:  "Shows the log of entries curset installed, updated and uninstalled in the .cursor/ folder, oldest first, with who made each change and which source and commit it came from. Pass an entry (e.g. rules/go) to show only its history.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		filter := ""
+		if len(args) == 1 {
+			if _, _, err := collection.SplitRef(args[0]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			filter = args[0]
+		}
+
+		forEachCursorDir(func(dir string) error {
+			events, err := manifest.ReadHistory(dir)
+			if err != nil {
+				return err
+			}
+
+			var rows [][]string
+			for _, e := range events {
+				if filter != "" && e.Entry != filter {
+					continue
+				}
+				rows = append(rows, []string{
+					e.Time.Local().Format("2006-01-02 15:04:05"),
+					e.Op,
+					e.Entry,
+					eventSource(e),
+					orNone(e.User),
+					orNone(e.CursetVersion),
+				})
+			}
+
+			if len(rows) == 0 {
+				fmt.Printf("No history recorded in %s\n", dir)
+				return nil
+			}
+			fmt.Println(newTable([]string{"time", "op", "entry", "source", "user", "version"}, rows))
+			return nil
+		})
+	},
+}
+
+// eventSource formats where an event's content came from, e.g. "owner/repo@main (1a2b3c4)".
+func eventSource(e manifest.Event) string {
+	s := orNone(e.Source)
+	if e.Ref != "" {
+		s += "@" + e.Ref
+	}
+	if e.ResolvedRef != "" {
+		commit := e.ResolvedRef
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		s += " (" + commit + ")"
+	}
+	return s
+}
@@ -5,34 +5,58 @@ import (
 	"os"
 
 	"github.com/bilgehannal/cursor-config/curset/internal/collection"
-	"github.com/bilgehannal/cursor-config/curset/internal/github"
-	"github.com/bilgehannal/cursor-config/curset/internal/installer"
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
 	"github.com/spf13/cobra"
 )
 
+var (
+	installOnly    []string
+	installExclude []string
+	installLink    bool
+	installAll     bool
+)
+
+// Flags shared by the commands that install entries, selecting how to install over
+// existing content that curset does not manage.
+var (
+	conflictForce  bool
+	conflictBackup bool
+	conflictRename bool
+)
+
 var installCmd = &cobra.Command{
 	Use:   "install [collection-name]",
 	Short: "Install a collection",
-	Long:  "Installs a named collection into the current directory's .cursor/ folder.",
-	Args:  cobra.ExactArgs(1),
+	Long: `Installs a named collection into the current directory's .cursor/ folder (or the user-level one with --global).
+
+--only and --exclude take object types ("rules") or entries ("rules/ansible"), and accept
+glob patterns ("rules/a*"). Filters are remembered, so reinstalling the collection without
+them applies the same filters again. --all drops the remembered filters and installs the
+full collection.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		name := args[0]
-		client := github.NewClient()
-
-		data, err := client.FetchCollectionJSON()
-		if err != nil {
+		filter := collection.Filter{Only: installOnly, Exclude: installExclude}
+		if err := filter.Validate(); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
-		cf, err := collection.Parse(data)
+		if installLink && !source.IsLocal(sourceFlag) {
+			fmt.Fprintln(os.Stderr, "Error: --link requires --source to be a local checkout path")
+			os.Exit(1)
+		}
+
+		src := source.Open(sourceFlag, refFlag)
+
+		cf, err := fetchCollectionFile(src)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
-		col, ok := cf.Collections[name]
-		if !ok {
+		if _, ok := cf.Collections[name]; !ok {
 			fmt.Fprintf(os.Stderr, "Error: collection '%s' not found\n", name)
 			fmt.Fprintln(os.Stderr, "\nAvailable collections:")
 			for _, n := range cf.SortedNames() {
@@ -41,15 +65,52 @@ var installCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		inst, err := installer.NewInstaller(client)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
-
-		if err := inst.Install(col, name); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
+		forEachCursorDir(func(dir string) error {
+			inst, err := newInstaller(src, dir)
+			if err != nil {
+				return err
+			}
+			defer inst.Close()
+			if err := snapshotBefore(inst, dir); err != nil {
+				return err
+			}
+			inst.SetLink(installLink)
+			inst.SetConflictMode(conflictMode())
+			if installAll {
+				inst.ResetFilter(name)
+			}
+			return inst.Install(cf, name, filter)
+		})
 	},
 }
+
+func init() {
+	installCmd.Flags().StringSliceVar(&installOnly, "only", nil, "Only install matching object types or entries (glob patterns allowed)")
+	installCmd.Flags().StringSliceVar(&installExclude, "exclude", nil, "Skip matching object types or entries (glob patterns allowed)")
+	installCmd.Flags().BoolVar(&installAll, "all", false, "Forget the remembered --only/--exclude filters and install the full collection")
+	installCmd.MarkFlagsMutuallyExclusive("all", "only")
+	installCmd.MarkFlagsMutuallyExclusive("all", "exclude")
+	installCmd.Flags().BoolVar(&installLink, "link", false, "Symlink entries from a local --source instead of copying them")
+	addConflictFlags(installCmd)
+}
+
+// addConflictFlags registers --force, --backup and --rename on cmd.
+func addConflictFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&conflictForce, "force", false, "Overwrite existing entries curset doesn't manage (uninstall restores them from the snapshot)")
+	cmd.Flags().BoolVar(&conflictBackup, "backup", false, "Move existing entries curset doesn't manage to .cursor/.curset-backups/ first (uninstall moves them back)")
+	cmd.Flags().BoolVar(&conflictRename, "rename", false, "Install beside existing entries curset doesn't manage, as <name>-curset")
+	cmd.MarkFlagsMutuallyExclusive("force", "backup", "rename")
+}
+
+// conflictMode returns the installer conflict mode selected by --force, --backup or --rename.
+func conflictMode() string {
+	switch {
+	case conflictForce:
+		return manifest.ConflictForce
+	case conflictBackup:
+		return manifest.ConflictBackup
+	case conflictRename:
+		return manifest.ConflictRename
+	}
+	return ""
+}
@@ -4,8 +4,7 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/bilgehannal/cursor-config/curset/internal/collection"
-	"github.com/bilgehannal/cursor-config/curset/internal/github"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
 	"github.com/spf13/cobra"
 )
 
@@ -14,15 +13,9 @@ var listCmd = &cobra.Command{
 	Short: "List available collections",
 	Long:  "Fetches the collection.json from the remote repository and displays all available collections.",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := github.NewClient()
+		src := source.Open(sourceFlag, refFlag)
 
-		data, err := client.FetchCollectionJSON()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
-
-		cf, err := collection.Parse(data)
+		cf, err := fetchCollectionFile(src)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
 	"github.com/spf13/cobra"
 )
 
@@ -19,72 +22,162 @@ var localCmd = &cobra.Command{
 var localListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List local .cursor contents as a collection",
-	Long:  "Scans the current directory's .cursor/ folder and displays its contents in collection.json format.",
+	Long:  "Scans the project's .cursor/ folder (or the user-level one with --global) and displays its contents in collection.json format.",
 	Run: func(cmd *cobra.Command, args []string) {
-		cursorDir := ".cursor"
-
-		info, err := os.Stat(cursorDir)
-		if err != nil || !info.IsDir() {
-			fmt.Fprintln(os.Stderr, "Error: .cursor/ directory not found in current directory")
+		dirs, err := cursorDirs()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
-		col := make(collection.Collection)
+		// A single folder is listed as the "local" collection; with several --dir
+		// folders each becomes a collection named after its project directory.
+		cf := &collection.CollectionFile{Collections: make(map[string]collection.Collection)}
+		for _, dir := range dirs {
+			col, err := scanCursorDir(dir)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			name := "local"
+			if len(dirs) > 1 {
+				name = filepath.Dir(dir)
+			}
+			cf.Collections[name] = col
+		}
 
-		// Read top-level directories under .cursor/ (e.g. rules, commands)
-		entries, err := os.ReadDir(cursorDir)
+		jsonStr, err := cf.ToJSON()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading .cursor/: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
-		for _, entry := range entries {
-			if !entry.IsDir() {
-				continue
-			}
+		fmt.Println(jsonStr)
+	},
+}
 
-			objType := entry.Name()
-			objPath := filepath.Join(cursorDir, objType)
+// scanCursorDir reads a .cursor/ folder into a collection.
+func scanCursorDir(cursorDir string) (collection.Collection, error) {
+	info, err := os.Stat(cursorDir)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s directory not found", cursorDir)
+	}
 
-			children, err := os.ReadDir(objPath)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", objPath, err)
-				continue
-			}
+	col := make(collection.Collection)
 
-			var items []string
-			for _, child := range children {
-				if child.IsDir() {
-					items = append(items, child.Name())
-				} else {
-					// Use filename without extension
-					name := child.Name()
-					ext := filepath.Ext(name)
-					items = append(items, strings.TrimSuffix(name, ext))
+	// Read top-level directories under .cursor/ (e.g. rules, commands)
+	entries, err := os.ReadDir(cursorDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", cursorDir, err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || manifest.IsStateFile(entry.Name()) {
+			continue
+		}
+
+		objType := entry.Name()
+		objPath := filepath.Join(cursorDir, objType)
+
+		children, err := os.ReadDir(objPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", objPath, err)
+			continue
+		}
+
+		var items []collection.Entry
+		for _, child := range children {
+			if child.IsDir() {
+				// Folders count as entries only if they contain files at some depth.
+				if hasFiles(filepath.Join(objPath, child.Name())) {
+					items = append(items, collection.Entry{Name: child.Name()})
 				}
+			} else {
+				// Use filename without extension
+				name := child.Name()
+				ext := filepath.Ext(name)
+				items = append(items, collection.Entry{Name: strings.TrimSuffix(name, ext)})
 			}
+		}
 
-			if len(items) > 0 {
-				col[objType] = items
-			}
+		if len(items) > 0 {
+			col[objType] = items
 		}
+	}
+
+	return col, nil
+}
 
-		cf := &collection.CollectionFile{
-			Collections: map[string]collection.Collection{
-				"local": col,
-			},
+var localChecksumsCmd = &cobra.Command{
+	Use:   "checksums",
+	Short: "Print SHA-256 checksums of local .cursor files",
+	Long:  "Hashes every file in the project's .cursor/ folder and prints the result as a collection.json \"checksums\" map. Run it in data/ to publish checksums for a collection repository.",
+	Run: func(cmd *cobra.Command, args []string) {
+		dirs, err := cursorDirs()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(dirs) > 1 {
+			fmt.Fprintln(os.Stderr, "Error: checksums takes a single directory")
+			os.Exit(1)
 		}
 
-		jsonStr, err := cf.ToJSON()
+		sums := make(collection.Checksums)
+		err = filepath.WalkDir(dirs[0], func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && manifest.IsStateFile(d.Name()) {
+				return filepath.SkipDir
+			}
+			if d.IsDir() || manifest.IsStateFile(d.Name()) {
+				return nil
+			}
+			rel, err := filepath.Rel(dirs[0], path)
+			if err != nil {
+				return err
+			}
+			sum, err := manifest.SumFile(path)
+			if err != nil {
+				return err
+			}
+			sums[filepath.ToSlash(rel)] = "sha256:" + sum.SHA256
+			return nil
+		})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 
-		fmt.Println(jsonStr)
+		data, err := json.MarshalIndent(map[string]collection.Checksums{"checksums": sums}, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Println(string(data))
 	},
 }
 
 func init() {
 	localCmd.AddCommand(localListCmd)
+	localCmd.AddCommand(localChecksumsCmd)
+}
+
+// hasFiles reports whether dir contains at least one file, searching subdirectories of any depth.
+func hasFiles(dir string) bool {
+	found := false
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			found = true
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return found
 }
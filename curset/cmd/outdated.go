@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/installer"
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var outdatedExitCode bool
+
+// outdatedStatus is the exit status of 'outdated --exit-code' when anything is outdated,
+// distinct from the status 1 of a failed check.
+const outdatedStatus = 2
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated",
+	Short: "Show installed collections and entries that are behind upstream",
+	Long: `Compares each installed entry with the latest content of the source and ref it was
+installed from, and each installed collection with the current collection.json of the source
+it was installed from, and prints the installed and latest commits side by side. --source
+only applies to collections installed before curset recorded their source.
+
+An entry is outdated when its upstream files changed; a new commit that doesn't touch it
+leaves it up to date. A collection is outdated when it now selects other entries or any of
+its entries is outdated. With --exit-code, curset exits with status 2 when anything is
+outdated, so CI can flag projects whose Cursor rules fall behind; status 1 means the check
+itself failed. Collections of an unknown source don't affect the status. The .cursor/ folder is only read, so outdated runs alongside other commands.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		src := source.Open(sourceFlag, refFlag)
+		fetch := collectionFetcher()
+
+		outdated := false
+		forEachCursorDir(func(dir string) error {
+			m, err := manifest.Load(dir)
+			if err != nil {
+				return err
+			}
+
+			report, err := installer.Outdated(src, m, fetch)
+			if err != nil {
+				return err
+			}
+			printOutdated(dir, report)
+			outdated = outdated || report.IsOutdated()
+			return nil
+		})
+
+		if outdatedExitCode && outdated {
+			os.Exit(outdatedStatus)
+		}
+	},
+}
+
+func init() {
+	outdatedCmd.Flags().BoolVar(&outdatedExitCode, "exit-code", false, "Exit with status 2 if anything is outdated")
+}
+
+// printOutdated prints the collection and entry tables of an outdated report.
+func printOutdated(dir string, report *installer.OutdatedReport) {
+	if len(report.Entries) == 0 && len(report.Collections) == 0 {
+		fmt.Printf("Nothing installed in %s\n", dir)
+		return
+	}
+
+	if len(report.Collections) > 0 {
+		rows := make([][]string, 0, len(report.Collections))
+		for _, c := range report.Collections {
+			var details []string
+			if len(c.Outdated) > 0 {
+				details = append(details, "outdated: "+strings.Join(c.Outdated, ", "))
+			}
+			if len(c.Added) > 0 {
+				details = append(details, "new: "+strings.Join(c.Added, ", "))
+			}
+			if len(c.Dropped) > 0 {
+				details = append(details, "dropped: "+strings.Join(c.Dropped, ", "))
+			}
+			rows = append(rows, []string{c.Name, c.Status, orNone(strings.Join(details, "; "))})
+		}
+		fmt.Println(newTable([]string{"collection", "status", "details"}, rows))
+	}
+
+	if len(report.Entries) > 0 {
+		rows := make([][]string, 0, len(report.Entries))
+		for _, e := range report.Entries {
+			rows = append(rows, []string{e.Entry, e.Source, shortCommit(e.Current), shortCommit(e.Latest), e.Status})
+		}
+		fmt.Println(newTable([]string{"entry", "source", "current", "latest", "status"}, rows))
+	}
+
+	if report.IsOutdated() {
+		fmt.Println("\nRun 'curset install' or 'curset sync' to update.")
+	} else {
+		fmt.Println("\nEverything is up to date.")
+	}
+}
+
+// shortCommit abbreviates a commit SHA for display, or returns "-" if it is unknown.
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return orNone(commit)
+}
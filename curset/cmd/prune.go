@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var pruneDryRun bool
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove managed entries that nothing references anymore",
+	Long:  "Removes entries from the .cursor/ folder that curset manages but that are no longer part of an installed collection, were not added directly, and are not required by another entry. This happens when a collection drops an entry upstream or disappears from collection.json. Each collection is checked against the collection.json of the source it was installed from; --source only applies to collections installed before curset recorded their source, which are never forgotten. Use --dry-run to see what would be removed.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		src := source.Open(sourceFlag, refFlag)
+		fetch := collectionFetcher()
+
+		forEachCursorDir(func(dir string) error {
+			inst, err := newInstaller(src, dir)
+			if err != nil {
+				return err
+			}
+			defer inst.Close()
+			if !pruneDryRun {
+				if err := snapshotBefore(inst, dir); err != nil {
+					return err
+				}
+			}
+			return inst.Prune(fetch, pruneDryRun)
+		})
+	},
+}
+
+func init() {
+	pruneCmd.Flags().BoolVarP(&pruneDryRun, "dry-run", "n", false, "List what would be removed without changing anything")
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var removeCmd = &cobra.Command{
+	Use:   "remove [type/entry]...",
+	Short: "Remove individually installed entries",
+	Long:  "Removes entries installed with 'curset add' from the current directory's .cursor/ folder, using the dependencies recorded in the manifest. Entries still used by an installed collection are kept.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		src := source.Open(sourceFlag, refFlag)
+
+		forEachCursorDir(func(dir string) error {
+			inst, err := newInstaller(src, dir)
+			if err != nil {
+				return err
+			}
+			defer inst.Close()
+			if err := snapshotBefore(inst, dir); err != nil {
+				return err
+			}
+			if err := recordEstimatedEntries(inst, src); err != nil {
+				return err
+			}
+			return inst.Remove(args)
+		})
+	},
+}
@@ -4,22 +4,46 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/config"
+	"github.com/bilgehannal/cursor-config/curset/internal/installer"
+	"github.com/bilgehannal/cursor-config/curset/internal/signature"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
 	"github.com/spf13/cobra"
 )
 
 var version = "dev"
 var gitignoreFlag bool
+var globalFlag bool
+var dirFlags []string
+var sourceFlag string
+var refFlag string
+var insecureFlag bool
 
 var rootCmd = &cobra.Command{
 	Use:   "curset",
 	Short: "Cursor config collection manager",
 	Long:  "curset is a CLI tool for managing .cursor folder configurations from curated collections.",
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		if gitignoreFlag {
-			if err := addCursorToGitignore(); err != nil {
+		if err := source.CheckRef(sourceFlag, refFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if gitignoreFlag && !globalFlag {
+			dirs, err := projectDirs()
+			if err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: failed to update .gitignore: %v\n", err)
+				return
+			}
+			for _, dir := range dirs {
+				if err := addCursorToGitignore(dir); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to update %s: %v\n", filepath.Join(dir, ".gitignore"), err)
+				}
 			}
 		}
 	},
@@ -35,17 +59,240 @@ func Execute(v string) {
 }
 
 func init() {
-	rootCmd.PersistentFlags().BoolVarP(&gitignoreFlag, "gitignore", "g", false, "Add .cursor/ to .gitignore in the current directory")
+	rootCmd.PersistentFlags().BoolVarP(&gitignoreFlag, "gitignore", "g", false, "Add .cursor/ to .gitignore in the project directory")
+	rootCmd.PersistentFlags().BoolVar(&globalFlag, "global", false, "Use the user-level .cursor/ folder (default ~/.cursor) instead of the current directory's")
+	rootCmd.PersistentFlags().StringVar(&sourceFlag, "source", "", "Repository to use: GitHub owner/repo or path to a local checkout (default bilgehannal/cursor-config)")
+	rootCmd.PersistentFlags().StringVar(&refFlag, "ref", "", "Branch, tag or commit of the GitHub repository (default main)")
+	rootCmd.PersistentFlags().BoolVar(&insecureFlag, "insecure", false, "Install even if collection.json's signature is missing or does not match a trusted key")
+	rootCmd.PersistentFlags().StringArrayVar(&dirFlags, "dir", nil, "Project directory to operate on instead of the current one; repeatable, glob patterns (e.g. 'services/*') select several")
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(installCmd)
 	rootCmd.AddCommand(uninstallCmd)
+	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(removeCmd)
+	rootCmd.AddCommand(syncCmd)
 	rootCmd.AddCommand(localCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(adoptCmd)
+	rootCmd.AddCommand(pruneCmd)
+	rootCmd.AddCommand(whyCmd)
+	rootCmd.AddCommand(graphCmd)
+	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(outdatedCmd)
+}
+
+// projectDirs resolves the --dir patterns into project directories.
+// Patterns may be globs (e.g. "services/*"); only existing directories are returned.
+func projectDirs() ([]string, error) {
+	patterns := dirFlags
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --dir pattern '%s': %w", pattern, err)
+		}
+
+		found := false
+		for _, m := range matches {
+			if info, err := os.Stat(m); err != nil || !info.IsDir() {
+				continue
+			}
+			found = true
+			m = filepath.Clean(m)
+			if !seen[m] {
+				seen[m] = true
+				dirs = append(dirs, m)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no directory matches '%s'", pattern)
+		}
+	}
+
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// cursorDirs returns the .cursor/ folders to operate on: one per project directory
+// selected by --dir, or the user-level folder when --global is set.
+func cursorDirs() ([]string, error) {
+	if globalFlag {
+		if len(dirFlags) > 0 {
+			return nil, fmt.Errorf("--global and --dir cannot be used together")
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, err
+		}
+		dir, err := cfg.GlobalCursorDir()
+		if err != nil {
+			return nil, err
+		}
+		return []string{dir}, nil
+	}
+
+	projects, err := projectDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make([]string, 0, len(projects))
+	for _, p := range projects {
+		dirs = append(dirs, filepath.Join(p, ".cursor"))
+	}
+	return dirs, nil
+}
+
+// forEachCursorDir runs fn for every .cursor/ folder selected by --dir or --global.
+// With several folders, each run is headed by its project directory, a failure does not
+// stop the remaining runs, and a summary is printed at the end.
+// Exits with status 1 if any run failed.
+func forEachCursorDir(fn func(dir string) error) {
+	dirs, err := cursorDirs()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(dirs) == 1 {
+		if err := fn(dirs[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	failed := false
+	rows := make([][]string, 0, len(dirs))
+	for _, dir := range dirs {
+		project := filepath.Dir(dir)
+		fmt.Printf("==> %s\n", project)
+
+		result := "ok"
+		if err := fn(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			result = "failed: " + err.Error()
+			failed = true
+		}
+		rows = append(rows, []string{project, result})
+		fmt.Println()
+	}
+
+	fmt.Println(newTable([]string{"directory", "result"}, rows))
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// newInstaller creates an installer for the .cursor/ folder dir that records this
+// curset version with the entries it installs.
+func newInstaller(src source.Source, dir string) (*installer.Installer, error) {
+	inst, err := installer.NewInstaller(src, dir)
+	if err != nil {
+		return nil, err
+	}
+	inst.SetVersion(version)
+	return inst, nil
+}
+
+// recordEstimatedEntries fetches collection.json to record the exact entries of collections
+// installed by an older curset, whose entries were estimated when the manifest was upgraded,
+// before a command that works from the manifest alone removes anything. If collection.json
+// is unavailable the estimate is used, which keeps rather than removes shared entries.
+func recordEstimatedEntries(inst *installer.Installer, src source.Source) error {
+	if !inst.HasEstimatedEntries() {
+		return nil
+	}
+	cf, err := fetchCollectionFile(src)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: collection entries recorded by an older curset are estimated and could not be updated: %v\n", err)
+		return nil
+	}
+	return inst.RecordEstimatedEntries(cf)
+}
+
+// fetchCollectionFile fetches and parses the collection.json of a source.
+// When trusted_keys are configured, the file must carry a valid signature from one
+// of them; --insecure downgrades a failed check to a warning.
+func fetchCollectionFile(src source.Source) (*collection.CollectionFile, error) {
+	data, err := src.FetchCollectionJSON()
+	if err != nil {
+		return nil, err
+	}
+	cf, err := collection.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := cfg.PublicKeys()
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return cf, nil
+	}
+
+	if err := verifyCollectionSignature(src, data, keys); err != nil {
+		if !insecureFlag {
+			return nil, fmt.Errorf("%w (use --insecure to install anyway)", err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return cf, nil
+	}
+	cf.Signed = true
+	return cf, nil
+}
+
+// collectionFetcher returns a fetchCollectionFile that fetches the collection.json of each
+// source once, for commands that read the sources installed collections came from in
+// several .cursor/ folders.
+func collectionFetcher() installer.FetchFunc {
+	type result struct {
+		cf  *collection.CollectionFile
+		err error
+	}
+	fetched := make(map[string]result)
+	return func(src source.Source) (*collection.CollectionFile, error) {
+		key := src.Repo() + "@" + src.Ref()
+		if r, ok := fetched[key]; ok {
+			return r.cf, r.err
+		}
+		cf, err := fetchCollectionFile(src)
+		fetched[key] = result{cf, err}
+		return cf, err
+	}
+}
+
+// verifyCollectionSignature checks collection.json against its minisign signature.
+func verifyCollectionSignature(src source.Source, data []byte, keys []signature.PublicKey) error {
+	sig, err := src.FetchCollectionSignature()
+	if err != nil {
+		return err
+	}
+	if _, err := signature.Verify(data, sig, keys); err != nil {
+		return fmt.Errorf("collection.json signature check failed: %w", err)
+	}
+	return nil
 }
 
-// addCursorToGitignore adds ".cursor/" to the current directory's .gitignore file.
+// addCursorToGitignore adds ".cursor/" to the .gitignore file in dir.
 // Creates the file if it doesn't exist. Skips if ".cursor/" is already present.
-func addCursorToGitignore() error {
-	const gitignorePath = ".gitignore"
+func addCursorToGitignore(dir string) error {
+	gitignorePath := filepath.Join(dir, ".gitignore")
 	const cursorEntry = ".cursor/"
 
 	// Check if .gitignore exists and already contains .cursor/
@@ -54,7 +301,7 @@ func addCursorToGitignore() error {
 		for scanner.Scan() {
 			line := strings.TrimSpace(scanner.Text())
 			if line == cursorEntry {
-				fmt.Println(".gitignore: .cursor/ already present")
+				fmt.Printf("%s: .cursor/ already present\n", gitignorePath)
 				return nil
 			}
 		}
@@ -89,6 +336,6 @@ func addCursorToGitignore() error {
 		return err
 	}
 
-	fmt.Println(".gitignore: added .cursor/")
+	fmt.Printf("%s: added .cursor/\n", gitignorePath)
 	return nil
 }
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/config"
+	"github.com/bilgehannal/cursor-config/curset/internal/search"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchTypes   []string
+	searchLimit   int
+	searchRefresh bool
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Search the content of rules and commands",
+	Long: `Searches the content of every rule and command of the source, whether a collection
+includes it or not, and lists the best matching files with the lines that match, the entry
+they belong to and the collections that include it. All words of the query must appear in a file; matches in file names,
+headings and the exact phrase rank higher.
+
+The content is indexed once per source and ref and cached in the curset cache folder
+(e.g. ~/.cache/curset). The index is rebuilt when the ref, or that of a source an entry
+overrides it with, points to a new commit, or with --refresh. If the source can't be reached, the cached index is used. Local --source
+checkouts are indexed on every search.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		query := strings.Join(args, " ")
+		src := source.Open(sourceFlag, refFlag)
+
+		idx, err := searchIndex(src)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		matches := idx.Search(query, searchTypes)
+		if len(matches) == 0 {
+			fmt.Printf("No matches for '%s'\n", query)
+			return
+		}
+
+		highlight := highlighter(search.Terms(query))
+		shown := matches
+		if searchLimit > 0 && len(shown) > searchLimit {
+			shown = shown[:searchLimit]
+		}
+		for i, m := range shown {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s  (%s · collections: %s)\n", lipgloss.NewStyle().Bold(true).Render(m.Path), m.Entry, orNone(strings.Join(m.Collections, ", ")))
+			for _, l := range m.Lines {
+				fmt.Printf("  %4d: %s\n", l.Number, highlight(l.Text))
+			}
+		}
+
+		fmt.Printf("\n%d of %d matching files", len(shown), len(matches))
+		if idx.Commit != "" {
+			fmt.Printf(" (index of %s at %.7s)", sourceLabel(src), idx.Commit)
+		}
+		fmt.Println()
+	},
+}
+
+func init() {
+	searchCmd.Flags().StringSliceVar(&searchTypes, "type", nil, "Only search entries of these object types (e.g. rules, commands)")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 10, "Show at most this many files (0 for all)")
+	searchCmd.Flags().BoolVar(&searchRefresh, "refresh", false, "Rebuild the cached index even if the source has not changed")
+}
+
+// searchIndex returns the search index of src: the cached one if src still points to the
+// commit it was built from (or can't be reached), otherwise a freshly built one.
+func searchIndex(src source.Source) (*search.Index, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	cacheDir, err := cfg.CacheDirPath()
+	if err != nil {
+		return nil, err
+	}
+
+	// Local checkouts can change without a commit, and are cheap to read.
+	cache := !source.IsLocal(src.Repo())
+	if cache && !searchRefresh {
+		cached, err := search.LoadCache(cacheDir, src)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if cached != nil {
+			changed, err := cached.Changed(src)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not check %s for changes (%v); using the index built %s\n",
+					sourceLabel(src), err, cached.Built.Local().Format("2006-01-02 15:04"))
+				return cached, nil
+			}
+			if !changed {
+				return cached, nil
+			}
+		}
+	}
+
+	cf, err := fetchCollectionFile(src)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(os.Stderr, "Indexing %s...\n", sourceLabel(src))
+	idx, err := search.Build(src, cf)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range idx.Skipped {
+		fmt.Fprintf(os.Stderr, "Warning: not indexed: %s\n", s)
+	}
+
+	if cache {
+		if err := idx.Save(cacheDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	return idx, nil
+}
+
+// highlighter returns a function that highlights the query terms in a line.
+func highlighter(terms []string) func(string) string {
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = regexp.QuoteMeta(t)
+	}
+	re := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+	style := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("214"))
+
+	return func(line string) string {
+		return re.ReplaceAllStringFunc(line, func(s string) string { return style.Render(s) })
+	}
+}
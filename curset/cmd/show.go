@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/mdc"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var showRender bool
+
+var showCmd = &cobra.Command{
+	Use:     "show [collection | type/entry]",
+	Aliases: []string{"info"},
+	Short:   "Show details of a collection or entry",
+	Long: `Shows a single collection or entry from the remote repository.
+
+For a collection: its entries with dependencies resolved, where each comes from, and the
+number and size of their files. For an entry (e.g. rules/go): its source, the collections
+that include it, its dependencies, and its files with sizes and, for .mdc rules, the
+frontmatter Cursor reads (alwaysApply, globs, description). --render also prints the
+rule and command markdown, formatted for the terminal.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		src := source.Open(sourceFlag, refFlag)
+
+		cf, err := fetchCollectionFile(src)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if strings.Contains(args[0], "/") {
+			err = showEntry(src, cf, args[0])
+		} else {
+			err = showCollection(src, cf, args[0])
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	showCmd.Flags().BoolVar(&showRender, "render", false, "Print the markdown of the entry's files, formatted for the terminal")
+}
+
+// showCollection prints a collection's resolved entries with their origin and file sizes.
+func showCollection(src source.Source, cf *collection.CollectionFile, name string) error {
+	col, ok := cf.Collections[name]
+	if !ok {
+		return fmt.Errorf("collection '%s' not found (available: %s)", name, strings.Join(cf.SortedNames(), ", "))
+	}
+
+	resolved, err := cf.Dependencies.Expand(col)
+	if err != nil {
+		return err
+	}
+	listed := make(map[string]bool)
+	for _, ref := range col.Refs() {
+		listed[ref] = true
+	}
+
+	fmt.Printf("Collection: %s\n", name)
+	fmt.Printf("Source:     %s\n\n", sourceLabel(src))
+
+	var rows [][]string
+	var problems []string
+	var total int64
+	for _, ref := range resolved.Refs() {
+		objType, entryName, _ := collection.SplitRef(ref)
+		e := cf.Lookup(objType, entryName)
+
+		origin := "listed"
+		if !listed[ref] {
+			origin = "dependency"
+		}
+		entrySrc, err := source.ForEntry(src, e)
+		if err != nil {
+			rows = append(rows, []string{ref, origin, "-", "-"})
+			problems = append(problems, err.Error())
+			continue
+		}
+		if e.HasOverride() {
+			origin += " from " + sourceLabel(entrySrc)
+		}
+
+		files, _, err := source.RemoteFiles(entrySrc, objType, entryName)
+		if err != nil {
+			rows = append(rows, []string{ref, origin, "-", "-"})
+			problems = append(problems, fmt.Sprintf("%s: %v", ref, err))
+			continue
+		}
+		var size int64
+		for _, f := range files {
+			size += f.Size
+		}
+		total += size
+		rows = append(rows, []string{ref, origin, strconv.Itoa(len(files)), formatSize(size)})
+	}
+
+	fmt.Println(newTable([]string{"entry", "origin", "files", "size"}, rows))
+	fmt.Printf("\n%d entries, %s\n", len(rows), formatSize(total))
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", p)
+	}
+	return nil
+}
+
+// showEntry prints an entry's origin, relationships and files, with the frontmatter of
+// .mdc rules and, with --render, the formatted markdown.
+func showEntry(src source.Source, cf *collection.CollectionFile, ref string) error {
+	objType, name, err := collection.SplitRef(ref)
+	if err != nil {
+		return err
+	}
+	e := cf.Lookup(objType, name)
+	entrySrc, err := source.ForEntry(src, e)
+	if err != nil {
+		return err
+	}
+
+	files, isDir, err := source.RemoteFiles(entrySrc, objType, name)
+	if err != nil {
+		return err
+	}
+
+	var listedIn, pulledIn []string
+	for _, colName := range cf.SortedNames() {
+		col := cf.Collections[colName]
+		resolved, err := cf.Dependencies.Expand(col)
+		if err != nil {
+			return err
+		}
+		switch {
+		case containsRef(col.Refs(), ref):
+			listedIn = append(listedIn, colName)
+		case containsRef(resolved.Refs(), ref):
+			pulledIn = append(pulledIn, colName)
+		}
+	}
+	var requiredBy []string
+	for dependent, deps := range cf.Dependencies {
+		if containsRef(deps, ref) {
+			requiredBy = append(requiredBy, dependent)
+		}
+	}
+	sort.Strings(requiredBy)
+
+	kind := "file"
+	if isDir {
+		kind = "directory"
+	}
+	fmt.Printf("Entry:       %s\n", ref)
+	fmt.Printf("Source:      %s\n", sourceLabel(entrySrc))
+	fmt.Printf("Kind:        %s\n", kind)
+	fmt.Printf("Collections: %s\n", orNone(strings.Join(listedIn, ", ")))
+	if len(pulledIn) > 0 {
+		fmt.Printf("Pulled in:   %s (as a dependency)\n", strings.Join(pulledIn, ", "))
+	}
+	fmt.Printf("Depends on:  %s\n", orNone(strings.Join(cf.Dependencies.Of(objType, name), ", ")))
+	fmt.Printf("Required by: %s\n\n", orNone(strings.Join(requiredBy, ", ")))
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var rows [][]string
+	bodies := make(map[string]string)
+	for _, p := range paths {
+		row := []string{p, formatSize(files[p].Size), "", "", ""}
+		isRule := filepath.Ext(p) == ".mdc"
+		if isRule || (showRender && filepath.Ext(p) == ".md") {
+			data, err := entrySrc.DownloadFile(filepath.ToSlash(p))
+			if err != nil {
+				return fmt.Errorf("failed to download %s: %w", p, err)
+			}
+			fm, body := mdc.Parse(data)
+			if isRule && fm.Present {
+				row[2] = strconv.FormatBool(fm.AlwaysApply)
+				row[3] = strings.Join(fm.Globs, ", ")
+				row[4] = truncate(fm.Description, 60)
+			}
+			bodies[p] = body
+		}
+		rows = append(rows, row)
+	}
+	fmt.Println(newTable([]string{"file", "size", "alwaysApply", "globs", "description"}, rows))
+
+	if showRender {
+		for _, p := range paths {
+			body, ok := bodies[p]
+			if !ok {
+				continue
+			}
+			fmt.Printf("\n── %s ──\n\n", p)
+			fmt.Print(mdc.Render(body))
+		}
+	}
+	return nil
+}
+
+// sourceLabel formats a source for display, e.g. "owner/repo@main" or a local path.
+func sourceLabel(src source.Source) string {
+	if src.Ref() == "" {
+		return src.Repo()
+	}
+	return src.Repo() + "@" + src.Ref()
+}
+
+// truncate shortens s to at most n runes, ending it with "…" if it was cut.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// containsRef reports whether refs contains ref.
+func containsRef(refs []string, ref string) bool {
+	for _, r := range refs {
+		if r == ref {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/config"
+	"github.com/bilgehannal/cursor-config/curset/internal/installer"
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+	"github.com/bilgehannal/cursor-config/curset/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var snapshotDropAll bool
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "List, restore and drop snapshots of the .cursor folder",
+	Long:  "curset saves a snapshot of the .cursor/ folder before every command that changes it. A snapshot holds what curset manages: the manifest, the installed entries and the backups made with --backup. Snapshots are kept in the curset state directory (default ~/.local/state/curset); the newest 20 are kept unless keep_snapshots is set in config.yaml.",
+}
+
+var snapshotLsCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List snapshots of the .cursor folder",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		forEachCursorDir(func(dir string) error {
+			store, _, err := openSnapshots(dir)
+			if err != nil {
+				return err
+			}
+			snaps, err := store.List()
+			if err != nil {
+				return err
+			}
+			if len(snaps) == 0 {
+				fmt.Printf("No snapshots of %s\n", dir)
+				return nil
+			}
+
+			rows := make([][]string, 0, len(snaps))
+			for _, s := range snaps {
+				rows = append(rows, []string{
+					s.ID,
+					s.Time.Local().Format("2006-01-02 15:04:05"),
+					orNone(s.Command),
+					fmt.Sprintf("%d", s.Files),
+					formatSize(s.Size),
+				})
+			}
+			fmt.Println(newTable([]string{"id", "time", "before", "files", "size"}, rows))
+			return nil
+		})
+	},
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore <id>",
+	Short: "Restore the .cursor folder to a snapshot",
+	Long:  "Replaces the manifest and installed entries of the .cursor/ folder with the ones saved in a snapshot. Entries installed since are removed, and content they were installed over with --force or --backup is put back. Content curset doesn't manage is left alone; if it is in the way of a saved entry, the restore is refused. The current state is saved as a new snapshot first, so a restore can be undone as well.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id := args[0]
+
+		forEachCursorDir(func(dir string) error {
+			store, _, err := openSnapshots(dir)
+			if err != nil {
+				return err
+			}
+			if _, err := store.Get(id); err != nil {
+				return err
+			}
+
+			lock, err := manifest.AcquireLock(dir)
+			if err != nil {
+				return err
+			}
+			defer lock.Release()
+
+			before, err := takeSnapshot(dir)
+			if err != nil {
+				return err
+			}
+			if err := store.Restore(id); err != nil {
+				return err
+			}
+
+			fmt.Printf("Restored %s to snapshot %s (previous state saved as %s).\n", dir, id, before.ID)
+			return nil
+		})
+	},
+}
+
+var snapshotDropCmd = &cobra.Command{
+	Use:   "drop [id...]",
+	Short: "Delete snapshots of the .cursor folder",
+	Long:  "Deletes snapshots of the .cursor/ folder. Snapshots holding content that an installed entry overwrote with --force are kept, since uninstalling the entry restores the content from them.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if snapshotDropAll == (len(args) > 0) {
+			return fmt.Errorf("pass snapshot IDs or --all")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		forEachCursorDir(func(dir string) error {
+			store, _, err := openSnapshots(dir)
+			if err != nil {
+				return err
+			}
+
+			ids := args
+			if snapshotDropAll {
+				snaps, err := store.List()
+				if err != nil {
+					return err
+				}
+				inUse, err := store.InUse()
+				if err != nil {
+					return err
+				}
+				ids = nil
+				for _, s := range snaps {
+					if refs := inUse[s.ID]; len(refs) > 0 {
+						fmt.Printf("  kept: %s (holds content overwritten by %s)\n", s.ID, strings.Join(refs, ", "))
+						continue
+					}
+					ids = append(ids, s.ID)
+				}
+			}
+
+			for _, id := range ids {
+				if err := store.Drop(id); err != nil {
+					return err
+				}
+				fmt.Printf("  dropped: %s\n", id)
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	snapshotDropCmd.Flags().BoolVar(&snapshotDropAll, "all", false, "Drop every snapshot of the .cursor folder")
+
+	snapshotCmd.AddCommand(snapshotLsCmd)
+	snapshotCmd.AddCommand(snapshotRestoreCmd)
+	snapshotCmd.AddCommand(snapshotDropCmd)
+}
+
+// openSnapshots returns the snapshot store of the .cursor/ folder dir and the user config.
+func openSnapshots(dir string) (*snapshot.Store, *config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	stateDir, err := cfg.StateDirPath()
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := snapshot.Open(stateDir, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store, cfg, nil
+}
+
+// takeSnapshot saves a snapshot of the .cursor/ folder dir before the running command
+// changes it, and drops the oldest snapshots beyond the configured limit.
+func takeSnapshot(dir string) (*snapshot.Snapshot, error) {
+	store, cfg, err := openSnapshots(dir)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := store.Take(strings.Join(append([]string{"curset"}, os.Args[1:]...), " "))
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Prune(cfg.SnapshotLimit()); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// snapshotBefore saves a snapshot of the .cursor/ folder dir before inst changes it, and
+// lets inst restore content it overwrites from that snapshot later.
+func snapshotBefore(inst *installer.Installer, dir string) error {
+	snap, err := takeSnapshot(dir)
+	if err != nil {
+		return err
+	}
+	store, _, err := openSnapshots(dir)
+	if err != nil {
+		return err
+	}
+	inst.SetSnapshot(store, snap.ID)
+	return nil
+}
+
+// formatSize formats a byte count for display, e.g. "12.3 KB".
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/config"
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show what curset has installed",
+	Long:  "Shows the collections and entries curset manages in the project's .cursor/ folder (one per --dir) and in the user-level one.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		globalDir, err := cfg.GlobalCursorDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		projects, err := projectDirs()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var scopes []struct{ name, dir string }
+		for _, p := range projects {
+			name := "project"
+			if len(projects) > 1 {
+				name = "project " + p
+			}
+			scopes = append(scopes, struct{ name, dir string }{name, filepath.Join(p, ".cursor")})
+		}
+		scopes = append(scopes, struct{ name, dir string }{"global", globalDir})
+
+		for i, scope := range scopes {
+			m, err := manifest.Load(scope.dir)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", scope.dir, err)
+				os.Exit(1)
+			}
+
+			fmt.Println(statusTable(scope.name, scope.dir, m))
+			if i < len(scopes)-1 {
+				fmt.Println()
+			}
+		}
+	},
+}
+
+// statusTable renders the contents of a manifest as a rounded table.
+func statusTable(scope, dir string, m *manifest.Manifest) *table.Table {
+	collections := make([]string, 0, len(m.Collections))
+	for _, name := range m.Collections {
+		if f := m.Filter(name); !f.IsEmpty() {
+			name = fmt.Sprintf("%s (%s)", name, f)
+		}
+		collections = append(collections, name)
+	}
+
+	rows := [][]string{
+		{"directory", dir},
+		{"collections", orNone(strings.Join(collections, ", "))},
+		{"added", orNone(strings.Join(m.Requested, ", "))},
+		{"entries", entriesSummary(m)},
+	}
+
+	return newTable([]string{scope, ""}, rows)
+}
+
+// orNone returns s, or "-" if s is empty.
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// entriesSummary counts the managed entries, e.g. "7 managed (2 linked)".
+func entriesSummary(m *manifest.Manifest) string {
+	linked := 0
+	for _, e := range m.Entries {
+		if e.Linked {
+			linked++
+		}
+	}
+
+	summary := fmt.Sprintf("%d managed", len(m.Entries))
+	if linked > 0 {
+		summary += fmt.Sprintf(" (%d linked)", linked)
+	}
+	return summary
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/project"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync .cursor/ with .curset.yaml",
+	Long: `Reads the project's .curset.yaml and converges the project's .cursor/ folder to it:
+declared collections and entries are installed or updated, and managed entries that are no longer
+declared are removed. Example .curset.yaml:
+
+  source: bilgehannal/cursor-config
+  ref: main
+  collections: [devops]
+  entries: [rules/go]
+  exclude: [rules/ansible, "rules/k*"]`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if globalFlag {
+			fmt.Fprintln(os.Stderr, "Error: sync applies a project's .curset.yaml and does not support --global")
+			os.Exit(1)
+		}
+
+		forEachCursorDir(func(dir string) error {
+			cfg, err := project.Load(filepath.Dir(dir))
+			if err != nil {
+				return err
+			}
+
+			// --source and --ref take precedence over the committed configuration.
+			// Another source doesn't inherit the configured ref; --ref alone applies to the configured source.
+			if sourceFlag != "" {
+				cfg.Source, cfg.Ref = sourceFlag, refFlag
+			} else if refFlag != "" {
+				cfg.Ref = refFlag
+			}
+			if err := source.CheckRef(cfg.Source, cfg.Ref); err != nil {
+				return err
+			}
+			src := source.Open(cfg.Source, cfg.Ref)
+
+			cf, err := fetchCollectionFile(src)
+			if err != nil {
+				return err
+			}
+
+			for _, name := range cfg.Collections {
+				if _, ok := cf.Collections[name]; !ok {
+					return fmt.Errorf("collection '%s' not found (available: %s)", name, strings.Join(cf.SortedNames(), ", "))
+				}
+			}
+
+			inst, err := newInstaller(src, dir)
+			if err != nil {
+				return err
+			}
+			defer inst.Close()
+			if err := snapshotBefore(inst, dir); err != nil {
+				return err
+			}
+			inst.SetConflictMode(conflictMode())
+
+			return inst.Sync(cf, cfg.Collections, cfg.Entries, collection.Filter{Exclude: cfg.Exclude})
+		})
+	},
+}
+
+func init() {
+	addConflictFlags(syncCmd)
+}
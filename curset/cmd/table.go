@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+)
+
+// newTable builds a rounded table in the same style as the collection tables.
+func newTable(headers []string, rows [][]string) *table.Table {
+	cellStyle := lipgloss.NewStyle().Padding(0, 1)
+	headerStyle := cellStyle.Bold(true)
+
+	return table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("240"))).
+		Headers(headers...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return headerStyle
+			}
+			return cellStyle
+		}).
+		Rows(rows...)
+}
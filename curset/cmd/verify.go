@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check installed files against their recorded checksums",
+	Long:  "Re-hashes the files curset installed into the .cursor/ folder and reports files that are missing, modified or unexpected. Exits with a non-zero status if anything does not match.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		src := source.Open(sourceFlag, refFlag)
+
+		forEachCursorDir(func(dir string) error {
+			inst, err := newInstaller(src, dir)
+			if err != nil {
+				return err
+			}
+			defer inst.Close()
+			return inst.Verify()
+		})
+	},
+}
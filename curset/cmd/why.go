@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+	"github.com/spf13/cobra"
+)
+
+var whyCmd = &cobra.Command{
+	Use:   "why [type/entry]",
+	Short: "Explain why an entry is installed",
+	Long: `Shows which installed collections and direct requests ('curset add') own an entry in the
+.cursor/ folder, and the dependency chain that pulled it in when the owner did not select it
+itself. The entry is removed only when its last owner is uninstalled or removed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ref := args[0]
+		objType, name, err := collection.SplitRef(ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		forEachCursorDir(func(dir string) error {
+			m, err := manifest.Load(dir)
+			if err != nil {
+				return err
+			}
+			if !m.IsManaged(objType, name) {
+				return fmt.Errorf("entry '%s' is not installed by curset in %s", ref, dir)
+			}
+
+			if len(m.EstimatedEntries) > 0 {
+				fmt.Fprintf(os.Stderr, "Note: the entries of %s were estimated when the manifest was upgraded from an older curset; run 'curset prune' to record them.\n\n", strings.Join(m.EstimatedEntries, ", "))
+			}
+
+			owners := m.Owners()[ref]
+			if len(owners) == 0 {
+				fmt.Printf("%s is not owned by any installed collection or direct request (remove it with 'curset prune')\n", ref)
+			} else {
+				fmt.Printf("%s is owned by:\n", ref)
+				for _, owner := range owners {
+					fmt.Printf("  - %s%s\n", manifest.DescribeOwner(owner), ownerChain(m, owner, ref))
+				}
+			}
+
+			dependents, deps := m.Dependents(objType, name), m.GetEntry(objType, name).DependsOn
+			if len(dependents) > 0 || len(deps) > 0 {
+				fmt.Println()
+			}
+			if len(dependents) > 0 {
+				fmt.Printf("Required by: %s\n", strings.Join(dependents, ", "))
+			}
+			if len(deps) > 0 {
+				fmt.Printf("Depends on: %s\n", strings.Join(deps, ", "))
+			}
+			return nil
+		})
+	},
+}
+
+// ownerChain describes how owner came to need ref: nothing when the owner selected ref
+// itself, otherwise the dependency chain from an entry it selected, e.g.
+// " (via rules/go -> rules/common)".
+func ownerChain(m *manifest.Manifest, owner, ref string) string {
+	roots := m.Requested
+	if name, ok := manifest.OwnerCollection(owner); ok {
+		roots = m.ListedBy(name)
+		if roots == nil {
+			roots = unrequiredEntries(m, name)
+		}
+	}
+
+	path := m.DependencyPath(roots, ref)
+	if len(path) < 2 {
+		return ""
+	}
+	return " (via " + strings.Join(path, " -> ") + ")"
+}
+
+// unrequiredEntries approximates the entries a collection lists itself, for manifests that
+// did not record them: the entries it selected that no other entry it selected depends on.
+func unrequiredEntries(m *manifest.Manifest, name string) []string {
+	selected := m.EntriesOf(name)
+	required := make(map[string]bool)
+	for _, r := range selected {
+		objType, entry, _ := collection.SplitRef(r)
+		if e := m.GetEntry(objType, entry); e != nil {
+			for _, dep := range e.DependsOn {
+				required[dep] = true
+			}
+		}
+	}
+	var roots []string
+	for _, r := range selected {
+		if !required[r] {
+			roots = append(roots, r)
+		}
+	}
+	return roots
+}
@@ -3,6 +3,8 @@ package collection
 import (
 	"encoding/json"
 	"fmt"
+	"path"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -12,13 +14,350 @@ import (
 
 // CollectionFile represents the top-level collection.json structure.
 type CollectionFile struct {
-	Collections map[string]Collection `json:"collections"`
+	Collections  map[string]Collection `json:"collections"`
+	Dependencies Dependencies          `json:"dependencies,omitempty"`
+	Checksums    Checksums             `json:"checksums,omitempty"`
+
+	// Signed is set once the file's signature has been verified against a trusted key.
+	// Every file installed from it must then match a published checksum.
+	Signed bool `json:"-"`
+}
+
+// Checksums maps a file path under data/.cursor/ (e.g. "rules/go/basic.mdc") to the
+// hex SHA-256 of its content, optionally prefixed with "sha256:".
+// Downloaded files are checked against it before they are written.
+type Checksums map[string]string
+
+// Of returns the expected hex SHA-256 of a file, or "" if none is published.
+func (c Checksums) Of(path string) string {
+	return strings.TrimPrefix(c[path], "sha256:")
 }
 
 // Collection represents a single named collection with dynamic object types.
 // Keys are object types like "rules", "commands", etc.
-// Values are lists of entry names.
-type Collection map[string][]string
+// Values are lists of entries.
+type Collection map[string][]Entry
+
+// Entry is a single item of a collection.
+// In collection.json it is either a plain name ("go") or an object that
+// overrides where the entry is fetched from, e.g.
+// {"name": "go", "source": "someone/cursor-config", "ref": "v2"}.
+type Entry struct {
+	Name   string `json:"name"`
+	Source string `json:"source,omitempty"` // GitHub repository "owner/repo"; empty means the default repository
+	Ref    string `json:"ref,omitempty"`    // branch, tag or commit; empty means the default ref
+}
+
+// UnmarshalJSON accepts either a plain string or an object.
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*e = Entry{Name: name}
+		return nil
+	}
+
+	type plain Entry
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return fmt.Errorf("entry must be a string or an object: %w", err)
+	}
+	if p.Name == "" {
+		return fmt.Errorf("entry object is missing \"name\"")
+	}
+	*e = Entry(p)
+	return nil
+}
+
+// MarshalJSON writes the entry as a plain string unless it overrides source or ref.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	if !e.HasOverride() {
+		return json.Marshal(e.Name)
+	}
+	type plain Entry
+	return json.Marshal(plain(e))
+}
+
+// HasOverride reports whether the entry sets its own source or ref.
+func (e Entry) HasOverride() bool {
+	return e.Source != "" || e.Ref != ""
+}
+
+// String returns the entry name, followed by its source and ref if overridden.
+// For example "go" or "go (someone/cursor-config@v2)".
+func (e Entry) String() string {
+	if !e.HasOverride() {
+		return e.Name
+	}
+	origin := e.Source
+	if e.Ref != "" {
+		origin += "@" + e.Ref
+	}
+	return fmt.Sprintf("%s (%s)", e.Name, origin)
+}
+
+// Dependencies maps an entry reference ("type/name", e.g. "commands/get-conflict-responsible")
+// to the entry references it requires.
+type Dependencies map[string][]string
+
+// Ref returns the "type/name" reference used to identify an entry.
+func Ref(objType, name string) string {
+	return objType + "/" + name
+}
+
+// SplitRef splits a "type/name" reference into its object type and entry name.
+func SplitRef(ref string) (objType, name string, err error) {
+	objType, name, ok := strings.Cut(ref, "/")
+	if !ok || objType == "" || name == "" {
+		return "", "", fmt.Errorf("invalid entry reference '%s' (expected type/name)", ref)
+	}
+	if err := ValidateName(objType); err != nil {
+		return "", "", fmt.Errorf("invalid entry reference '%s': %w", ref, err)
+	}
+	if err := ValidateName(name); err != nil {
+		return "", "", fmt.Errorf("invalid entry reference '%s': %w", ref, err)
+	}
+	return objType, name, nil
+}
+
+// ValidateName checks that an object type, entry name or file name is a single, plain
+// path component, so that joining it under .cursor/ can never escape that folder.
+func ValidateName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("empty name")
+	case name == "." || name == "..":
+		return fmt.Errorf("name '%s' is not allowed", name)
+	case len(name) > 255:
+		return fmt.Errorf("name '%.32s...' is longer than 255 bytes", name)
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("name '%s' must not contain path separators", name)
+	}
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("name %q must not contain control characters", name)
+		}
+	}
+	return nil
+}
+
+// repoPattern matches a GitHub "owner/repo".
+var repoPattern = regexp.MustCompile(`^[A-Za-z0-9-]+/[A-Za-z0-9._-]+$`)
+
+// ValidateRepo checks that repo is a GitHub "owner/repo". Local paths are refused: only the
+// user's own --source or config may point curset at a local checkout, never collection.json.
+func ValidateRepo(repo string) error {
+	_, name, _ := strings.Cut(repo, "/")
+	if !repoPattern.MatchString(repo) || name == "." || name == ".." {
+		return fmt.Errorf("source %q is not a GitHub owner/repo", repo)
+	}
+	return nil
+}
+
+// ValidateRef checks that a branch, tag or commit is safe to put in a GitHub URL: not empty,
+// without "..", whitespace, control characters or the characters git and URLs reserve.
+func ValidateRef(ref string) error {
+	switch {
+	case ref == "":
+		return fmt.Errorf("empty ref")
+	case len(ref) > 255:
+		return fmt.Errorf("ref '%.32s...' is longer than 255 bytes", ref)
+	case strings.Contains(ref, ".."):
+		return fmt.Errorf("ref '%s' must not contain '..'", ref)
+	case strings.HasPrefix(ref, "-") || strings.HasPrefix(ref, "/"):
+		return fmt.Errorf("ref '%s' must not start with '%c'", ref, ref[0])
+	case strings.ContainsAny(ref, `~^:?*[\#%&`):
+		return fmt.Errorf("ref '%s' must not contain any of ~^:?*[\\#%%&", ref)
+	}
+	for _, r := range ref {
+		if r <= 0x20 || r == 0x7f {
+			return fmt.Errorf("ref %q must not contain spaces or control characters", ref)
+		}
+	}
+	return nil
+}
+
+// Of returns the direct dependencies of an entry.
+func (d Dependencies) Of(objType, name string) []string {
+	return d[Ref(objType, name)]
+}
+
+// Expand returns a copy of col with all transitive dependencies of its entries added.
+// Dependencies already present in col are not duplicated.
+func (d Dependencies) Expand(col Collection) (Collection, error) {
+	return d.expand(col, func(objType, name string) Entry { return Entry{Name: name} })
+}
+
+// expand is Expand, with the entries of pulled-in dependencies returned by lookup.
+func (d Dependencies) expand(col Collection, lookup func(objType, name string) Entry) (Collection, error) {
+	out := make(Collection, len(col))
+	seen := make(map[string]bool)
+	var queue []string
+
+	for objType, entries := range col {
+		for _, e := range entries {
+			out[objType] = append(out[objType], e)
+			ref := Ref(objType, e.Name)
+			seen[ref] = true
+			queue = append(queue, ref)
+		}
+	}
+	sort.Strings(queue)
+
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+
+		for _, dep := range d[ref] {
+			if seen[dep] {
+				continue
+			}
+			objType, name, err := SplitRef(dep)
+			if err != nil {
+				return nil, fmt.Errorf("dependency of %s: %w", ref, err)
+			}
+			seen[dep] = true
+			out[objType] = append(out[objType], lookup(objType, name))
+			queue = append(queue, dep)
+		}
+	}
+
+	return out, nil
+}
+
+// Refs returns the entries of c as sorted "type/name" references.
+func (c Collection) Refs() []string {
+	var refs []string
+	for objType, entries := range c {
+		for _, e := range entries {
+			refs = append(refs, Ref(objType, e.Name))
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// Merge combines collections into one, keeping the first occurrence of each entry.
+func Merge(cols ...Collection) Collection {
+	out := make(Collection)
+	seen := make(map[string]bool)
+	for _, col := range cols {
+		for objType, entries := range col {
+			for _, e := range entries {
+				ref := Ref(objType, e.Name)
+				if seen[ref] {
+					continue
+				}
+				seen[ref] = true
+				out[objType] = append(out[objType], e)
+			}
+		}
+	}
+	return out
+}
+
+// Filter selects which entries of a collection are installed.
+// Patterns are either an object type ("rules") or a "type/name" reference, and may use
+// glob syntax ("rules/a*"). An empty Only list selects everything.
+type Filter struct {
+	Only    []string `json:"only,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// IsEmpty reports whether the filter selects every entry.
+func (f Filter) IsEmpty() bool {
+	return len(f.Only) == 0 && len(f.Exclude) == 0
+}
+
+// Validate checks that all patterns are well-formed.
+func (f Filter) Validate() error {
+	for _, p := range append(append([]string{}, f.Only...), f.Exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern '%s': %w", p, err)
+		}
+	}
+	return nil
+}
+
+// Includes reports whether the filter selects the given entry.
+func (f Filter) Includes(objType, name string) bool {
+	if len(f.Only) > 0 && !matchAny(f.Only, objType, name) {
+		return false
+	}
+	return !matchAny(f.Exclude, objType, name)
+}
+
+// Apply returns the entries of col selected by the filter.
+func (f Filter) Apply(col Collection) Collection {
+	out := make(Collection, len(col))
+	for objType, entries := range col {
+		for _, e := range entries {
+			if f.Includes(objType, e.Name) {
+				out[objType] = append(out[objType], e)
+			}
+		}
+	}
+	return out
+}
+
+// String describes the filter, e.g. "only rules, exclude rules/ansible".
+func (f Filter) String() string {
+	var parts []string
+	if len(f.Only) > 0 {
+		parts = append(parts, "only "+strings.Join(f.Only, ", "))
+	}
+	if len(f.Exclude) > 0 {
+		parts = append(parts, "exclude "+strings.Join(f.Exclude, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// matchAny reports whether any pattern matches the entry. Patterns without a "/"
+// are matched against the object type, the others against the "type/name" reference.
+func matchAny(patterns []string, objType, name string) bool {
+	for _, p := range patterns {
+		target := Ref(objType, name)
+		if !strings.Contains(p, "/") {
+			target = objType
+		}
+		if ok, _ := path.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the entry objType/name as listed by the first collection of cf (by name)
+// that lists it, including its source and ref overrides, or a plain entry if none does.
+func (cf *CollectionFile) Lookup(objType, name string) Entry {
+	for _, colName := range cf.SortedNames() {
+		for _, e := range cf.Collections[colName][objType] {
+			if e.Name == name {
+				return e
+			}
+		}
+	}
+	return Entry{Name: name}
+}
+
+// Entries groups "type/name" references into a collection, with each entry as declared in
+// collection.json (see Lookup), so that it is fetched from its source and ref override.
+func (cf *CollectionFile) Entries(refs []string) (Collection, error) {
+	col := make(Collection)
+	for _, ref := range refs {
+		objType, name, err := SplitRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		col[objType] = append(col[objType], cf.Lookup(objType, name))
+	}
+	return col, nil
+}
+
+// Expand returns a copy of col with all transitive dependencies of its entries added, like
+// Dependencies.Expand, with each pulled-in entry as declared in collection.json (see Lookup).
+func (cf *CollectionFile) Expand(col Collection) (Collection, error) {
+	return cf.Dependencies.expand(col, cf.Lookup)
+}
 
 // Parse parses the collection.json bytes into a CollectionFile.
 func Parse(data []byte) (*CollectionFile, error) {
@@ -26,9 +365,49 @@ func Parse(data []byte) (*CollectionFile, error) {
 	if err := json.Unmarshal(data, &cf); err != nil {
 		return nil, fmt.Errorf("failed to parse collection.json: %w", err)
 	}
+	if err := cf.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid collection.json: %w", err)
+	}
 	return &cf, nil
 }
 
+// Validate checks every object type, entry name, source and ref override and dependency reference.
+func (cf *CollectionFile) Validate() error {
+	for _, name := range cf.SortedNames() {
+		for objType, entries := range cf.Collections[name] {
+			if err := ValidateName(objType); err != nil {
+				return fmt.Errorf("collection '%s': object type: %w", name, err)
+			}
+			for _, e := range entries {
+				if err := ValidateName(e.Name); err != nil {
+					return fmt.Errorf("collection '%s': %s entry: %w", name, objType, err)
+				}
+				if e.Source != "" {
+					if err := ValidateRepo(e.Source); err != nil {
+						return fmt.Errorf("collection '%s': %s/%s: %w", name, objType, e.Name, err)
+					}
+				}
+				if e.Ref != "" {
+					if err := ValidateRef(e.Ref); err != nil {
+						return fmt.Errorf("collection '%s': %s/%s: %w", name, objType, e.Name, err)
+					}
+				}
+			}
+		}
+	}
+	for ref, deps := range cf.Dependencies {
+		if _, _, err := SplitRef(ref); err != nil {
+			return fmt.Errorf("dependencies: %w", err)
+		}
+		for _, dep := range deps {
+			if _, _, err := SplitRef(dep); err != nil {
+				return fmt.Errorf("dependencies of %s: %w", ref, err)
+			}
+		}
+	}
+	return nil
+}
+
 // SortedNames returns the collection names sorted alphabetically.
 func (cf *CollectionFile) SortedNames() []string {
 	names := make([]string, 0, len(cf.Collections))
@@ -63,7 +442,11 @@ func (cf *CollectionFile) PrintTables() {
 		// Build rows
 		rows := make([][]string, 0, len(keys))
 		for _, k := range keys {
-			rows = append(rows, []string{k, strings.Join(col[k], ", ")})
+			items := make([]string, 0, len(col[k]))
+			for _, e := range col[k] {
+				items = append(items, e.String())
+			}
+			rows = append(rows, []string{k, strings.Join(items, ", ")})
 		}
 
 		t := table.New().
@@ -0,0 +1,139 @@
+package collection
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "plain", input: "common"},
+		{name: "with extension", input: "clean-code.mdc"},
+		{name: "leading dot", input: ".hidden"},
+		{name: "dots inside", input: "a..b"},
+		{name: "unicode", input: "règles"},
+		{name: "empty", input: "", wantErr: "empty name"},
+		{name: "current dir", input: ".", wantErr: "not allowed"},
+		{name: "parent dir", input: "..", wantErr: "not allowed"},
+		{name: "slash", input: "../etc", wantErr: "path separators"},
+		{name: "nested", input: "rules/go", wantErr: "path separators"},
+		{name: "backslash", input: `..\evil`, wantErr: "path separators"},
+		{name: "newline", input: "a\nb", wantErr: "control characters"},
+		{name: "NUL", input: "a\x00b", wantErr: "control characters"},
+		{name: "DEL", input: "a\x7fb", wantErr: "control characters"},
+		{name: "255 bytes", input: strings.Repeat("a", 255)},
+		{name: "256 bytes", input: strings.Repeat("a", 256), wantErr: "longer than 255 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateName(tt.input)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateName(%q) error = %v", tt.input, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateName(%q) error = %v, want it to contain %q", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   string
+		wantErr string
+	}{
+		{name: "plain name", entry: `"go"`},
+		{name: "GitHub source", entry: `{"name": "go", "source": "someone/cursor-config"}`},
+		{name: "source with dots", entry: `{"name": "go", "source": "some-one/cursor.config_2"}`},
+		{name: "branch ref", entry: `{"name": "go", "ref": "feature/go-rules"}`},
+		{name: "tag ref", entry: `{"name": "go", "source": "someone/cursor-config", "ref": "v2.1.0"}`},
+		{name: "absolute path source", entry: `{"name": "go", "source": "/etc"}`, wantErr: "not a GitHub owner/repo"},
+		{name: "home path source", entry: `{"name": "go", "source": "~/.ssh"}`, wantErr: "not a GitHub owner/repo"},
+		{name: "relative path source", entry: `{"name": "go", "source": "./checkout"}`, wantErr: "not a GitHub owner/repo"},
+		{name: "source without repo", entry: `{"name": "go", "source": "someone"}`, wantErr: "not a GitHub owner/repo"},
+		{name: "source with extra segment", entry: `{"name": "go", "source": "someone/repo/contents"}`, wantErr: "not a GitHub owner/repo"},
+		{name: "source parent repo", entry: `{"name": "go", "source": "someone/.."}`, wantErr: "not a GitHub owner/repo"},
+		{name: "source with query", entry: `{"name": "go", "source": "someone/repo?x=1"}`, wantErr: "not a GitHub owner/repo"},
+		{name: "ref with dot-dot", entry: `{"name": "go", "ref": "../../main"}`, wantErr: "must not contain '..'"},
+		{name: "ref with space", entry: `{"name": "go", "ref": "v2 main"}`, wantErr: "spaces or control characters"},
+		{name: "ref with newline", entry: `{"name": "go", "ref": "v2\nmain"}`, wantErr: "spaces or control characters"},
+		{name: "ref with query", entry: `{"name": "go", "ref": "main?x=1"}`, wantErr: "must not contain any of"},
+		{name: "ref starting with dash", entry: `{"name": "go", "ref": "-v2"}`, wantErr: "must not start with"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(`{"collections": {"go": {"rules": [` + tt.entry + `]}}}`))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Parse() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEntriesAndExpandUseOverrides(t *testing.T) {
+	cf, err := Parse([]byte(`{
+		"collections": {
+			"base":  {"rules": ["redis"]},
+			"team":  {"rules": [{"name": "go", "source": "someone/cursor-config", "ref": "v2"}, "redis"]},
+			"tools": {"commands": [{"name": "review", "ref": "v3"}]}
+		},
+		"dependencies": {"rules/redis": ["rules/go", "commands/review"]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	goEntry := Entry{Name: "go", Source: "someone/cursor-config", Ref: "v2"}
+	review := Entry{Name: "review", Ref: "v3"}
+
+	tests := []struct {
+		name string
+		refs []string
+		want map[string]Entry // "type/name" -> entry
+	}{
+		{name: "declared with an override", refs: []string{"rules/go"}, want: map[string]Entry{"rules/go": goEntry}},
+		{name: "declared without an override", refs: []string{"rules/redis"}, want: map[string]Entry{
+			"rules/redis": {Name: "redis"}, "rules/go": goEntry, "commands/review": review,
+		}},
+		{name: "not declared", refs: []string{"rules/python"}, want: map[string]Entry{"rules/python": {Name: "python"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			col, err := cf.Entries(tt.refs)
+			if err != nil {
+				t.Fatalf("Entries() error = %v", err)
+			}
+			col, err = cf.Expand(col)
+			if err != nil {
+				t.Fatalf("Expand() error = %v", err)
+			}
+			got := make(map[string]Entry)
+			for objType, entries := range col {
+				for _, e := range entries {
+					got[Ref(objType, e.Name)] = e
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for ref, want := range tt.want {
+				if got[ref] != want {
+					t.Errorf("%s = %+v, want %+v", ref, got[ref], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package collection
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Graph formats accepted by CollectionFile.Graph.
+const (
+	GraphDOT     = "dot"
+	GraphMermaid = "mermaid"
+)
+
+// graphEdge links a collection to an entry it lists, or an entry to a dependency.
+type graphEdge struct {
+	from, to   string
+	dependency bool
+}
+
+// Graph renders the collection→entry relationships and the dependencies between
+// entries in the given format (GraphDOT or GraphMermaid). Collections are drawn as
+// boxes; dependency edges are dashed.
+func (cf *CollectionFile) Graph(format string) (string, error) {
+	var edges []graphEdge
+	entries := make(map[string]bool)
+
+	for _, name := range cf.SortedNames() {
+		for _, ref := range cf.Collections[name].Refs() {
+			edges = append(edges, graphEdge{from: "collection:" + name, to: ref})
+			entries[ref] = true
+		}
+	}
+
+	refs := make([]string, 0, len(cf.Dependencies))
+	for ref := range cf.Dependencies {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		deps := append([]string(nil), cf.Dependencies[ref]...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			edges = append(edges, graphEdge{from: ref, to: dep, dependency: true})
+			entries[ref], entries[dep] = true, true
+		}
+	}
+
+	nodes := make([]string, 0, len(cf.Collections)+len(entries))
+	for _, name := range cf.SortedNames() {
+		nodes = append(nodes, "collection:"+name)
+	}
+	entryRefs := make([]string, 0, len(entries))
+	for ref := range entries {
+		entryRefs = append(entryRefs, ref)
+	}
+	sort.Strings(entryRefs)
+	nodes = append(nodes, entryRefs...)
+
+	if err := CheckGraphFormat(format); err != nil {
+		return "", err
+	}
+	if format == GraphMermaid {
+		return graphMermaid(nodes, edges), nil
+	}
+	return graphDOT(nodes, edges), nil
+}
+
+// CheckGraphFormat returns an error unless format is GraphDOT or GraphMermaid.
+func CheckGraphFormat(format string) error {
+	if format != GraphDOT && format != GraphMermaid {
+		return fmt.Errorf("unknown graph format '%s' (expected %s or %s)", format, GraphDOT, GraphMermaid)
+	}
+	return nil
+}
+
+// nodeLabel returns the text shown for a node: the collection name or the entry reference.
+func nodeLabel(node string) (label string, isCollection bool) {
+	if name, ok := strings.CutPrefix(node, "collection:"); ok {
+		return name, true
+	}
+	return node, false
+}
+
+// graphDOT renders the graph as a Graphviz digraph. Node IDs and labels are quoted with
+// dotQuote; collections are boxes and entries ellipses.
+func graphDOT(nodes []string, edges []graphEdge) string {
+	var b strings.Builder
+	b.WriteString("digraph curset {\n  rankdir=LR;\n")
+	for _, node := range nodes {
+		label, isCollection := nodeLabel(node)
+		shape := "ellipse"
+		if isCollection {
+			shape = "box"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s];\n", dotQuote(node), dotQuote(label), shape)
+	}
+	for _, e := range edges {
+		style := ""
+		if e.dependency {
+			style = " [style=dashed]"
+		}
+		fmt.Fprintf(&b, "  %s -> %s%s;\n", dotQuote(e.from), dotQuote(e.to), style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// graphMermaid renders the graph as a Mermaid flowchart. Collections are rectangles and
+// entries stadiums, with labels escaped by mermaidLabel.
+func graphMermaid(nodes []string, edges []graphEdge) string {
+	// Mermaid node IDs can't contain "/" or ":", so nodes are numbered and labelled.
+	ids := make(map[string]string, len(nodes))
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for i, node := range nodes {
+		ids[node] = fmt.Sprintf("n%d", i)
+		label, isCollection := nodeLabel(node)
+		if isCollection {
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[node], mermaidLabel(label))
+		} else {
+			fmt.Fprintf(&b, "  %s([\"%s\"])\n", ids[node], mermaidLabel(label))
+		}
+	}
+	for _, e := range edges {
+		arrow := "-->"
+		if e.dependency {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", ids[e.from], arrow, ids[e.to])
+	}
+	return b.String()
+}
+
+// dotQuote returns s as a DOT double-quoted string. DOT files are UTF-8, so unlike Go's %q
+// it keeps non-ASCII characters as they are, and only escapes quotes and backslashes.
+// Line breaks become DOT's "\n" escape; other control characters are dropped.
+func dotQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r < 0x20 || r == 0x7f:
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// mermaidLabel escapes s for a quoted Mermaid label. Characters Mermaid would read as
+// markup or as the end of the label are written as entity codes; control characters
+// become spaces.
+func mermaidLabel(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString("#quot;")
+		case r == '#':
+			b.WriteString("#35;")
+		case r == '&':
+			b.WriteString("#amp;")
+		case r == '<':
+			b.WriteString("#lt;")
+		case r == '>':
+			b.WriteString("#gt;")
+		case r < 0x20 || r == 0x7f:
+			b.WriteByte(' ')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package collection
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDotQuote(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{input: "rules/go", want: `"rules/go"`},
+		{input: "règles", want: `"règles"`},
+		{input: `say "hi"`, want: `"say \"hi\""`},
+		{input: `a\b`, want: `"a\\b"`},
+		{input: "two\nlines", want: `"two\nlines"`},
+		{input: "bell\a", want: `"bell"`},
+	}
+	for _, tt := range tests {
+		if got := dotQuote(tt.input); got != tt.want {
+			t.Errorf("dotQuote(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMermaidLabel(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{input: "rules/go", want: "rules/go"},
+		{input: "règles", want: "règles"},
+		{input: `say "hi"`, want: "say #quot;hi#quot;"},
+		{input: "#1 <b>&", want: "#35;1 #lt;b#gt;#amp;"},
+		{input: "two\nlines", want: "two lines"},
+	}
+	for _, tt := range tests {
+		if got := mermaidLabel(tt.input); got != tt.want {
+			t.Errorf("mermaidLabel(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestGraph(t *testing.T) {
+	cf, err := Parse([]byte(`{
+		"collections": {"règles \"fr\"": {"rules": ["français"]}},
+		"dependencies": {"rules/français": ["rules/common"]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{format: GraphDOT, want: []string{
+			`"collection:règles \"fr\"" [label="règles \"fr\"", shape=box];`,
+			`"collection:règles \"fr\"" -> "rules/français";`,
+			`"rules/français" -> "rules/common" [style=dashed];`,
+		}},
+		{format: GraphMermaid, want: []string{
+			`n0["règles #quot;fr#quot;"]`,
+			`n2(["rules/français"])`,
+			`n0 --> n2`,
+			`n2 -.-> n1`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out, err := cf.Graph(tt.format)
+			if err != nil {
+				t.Fatalf("Graph() error = %v", err)
+			}
+			for _, line := range tt.want {
+				if !strings.Contains(out, line) {
+					t.Errorf("Graph(%s) is missing %s:\n%s", tt.format, line, out)
+				}
+			}
+		})
+	}
+
+	if _, err := cf.Graph("svg"); err == nil || !strings.Contains(err.Error(), "unknown graph format") {
+		t.Errorf("Graph(svg) error = %v, want an unknown format error", err)
+	}
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/signature"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// globalDirEnv overrides the configured global directory when set.
+	globalDirEnv = "CURSET_GLOBAL_DIR"
+	// stateDirEnv overrides the configured state directory when set.
+	stateDirEnv = "CURSET_STATE_DIR"
+	// cacheDirEnv overrides the configured cache directory when set.
+	cacheDirEnv = "CURSET_CACHE_DIR"
+
+	// DefaultKeepSnapshots is how many snapshots are kept per .cursor/ folder by default.
+	DefaultKeepSnapshots = 20
+)
+
+// Config is the user-level curset configuration, read from config.yaml in the
+// curset folder of the user config directory (e.g. ~/.config/curset/config.yaml).
+type Config struct {
+	GlobalDir   string   `yaml:"global_dir,omitempty"`   // user-level .cursor/ folder; defaults to ~/.cursor
+	TrustedKeys []string `yaml:"trusted_keys,omitempty"` // minisign public keys collection.json must be signed with
+
+	StateDir      string `yaml:"state_dir,omitempty"`      // where snapshots are kept; defaults to ~/.local/state/curset
+	KeepSnapshots int    `yaml:"keep_snapshots,omitempty"` // snapshots kept per .cursor/ folder; defaults to DefaultKeepSnapshots
+	CacheDir      string `yaml:"cache_dir,omitempty"`      // where downloaded data (e.g. the search index) is cached; defaults to ~/.cache/curset
+}
+
+// Path returns the location of the user-level configuration file.
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user config directory: %w", err)
+	}
+	return filepath.Join(dir, "curset", "config.yaml"), nil
+}
+
+// Load reads the user-level configuration.
+// Returns an empty configuration if the file does not exist.
+func Load() (*Config, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// GlobalCursorDir returns the user-level .cursor/ folder.
+// The CURSET_GLOBAL_DIR environment variable takes precedence over global_dir,
+// and a leading "~" is expanded to the home directory.
+func (c *Config) GlobalCursorDir() (string, error) {
+	dir := c.GlobalDir
+	if env := os.Getenv(globalDirEnv); env != "" {
+		dir = env
+	}
+
+	if dir == "" {
+		dir = "~/.cursor"
+	}
+	return expandHome(dir)
+}
+
+// StateDirPath returns the folder curset keeps its state (e.g. snapshots) in.
+// The CURSET_STATE_DIR environment variable takes precedence over state_dir; the
+// default is $XDG_STATE_HOME/curset, or ~/.local/state/curset.
+func (c *Config) StateDirPath() (string, error) {
+	dir := c.StateDir
+	if env := os.Getenv(stateDirEnv); env != "" {
+		dir = env
+	}
+
+	if dir == "" {
+		if xdg := os.Getenv("XDG_STATE_HOME"); xdg != "" {
+			return filepath.Join(xdg, "curset"), nil
+		}
+		dir = "~/.local/state/curset"
+	}
+	return expandHome(dir)
+}
+
+// CacheDirPath returns the folder curset caches downloaded data in. The CURSET_CACHE_DIR
+// environment variable takes precedence over cache_dir; the default is the curset folder
+// of the user cache directory (e.g. ~/.cache/curset).
+func (c *Config) CacheDirPath() (string, error) {
+	dir := c.CacheDir
+	if env := os.Getenv(cacheDirEnv); env != "" {
+		dir = env
+	}
+
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+		}
+		return filepath.Join(base, "curset"), nil
+	}
+	return expandHome(dir)
+}
+
+// SnapshotLimit returns how many snapshots to keep per .cursor/ folder.
+func (c *Config) SnapshotLimit() int {
+	if c.KeepSnapshots > 0 {
+		return c.KeepSnapshots
+	}
+	return DefaultKeepSnapshots
+}
+
+// expandHome expands a leading "~" in dir to the home directory.
+func expandHome(dir string) (string, error) {
+	if dir != "~" && !strings.HasPrefix(dir, "~/") {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(dir, "~")), nil
+}
+
+// PublicKeys parses the trusted minisign public keys.
+func (c *Config) PublicKeys() ([]signature.PublicKey, error) {
+	keys := make([]signature.PublicKey, 0, len(c.TrustedKeys))
+	for _, k := range c.TrustedKeys {
+		key, err := signature.ParsePublicKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("trusted_keys: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
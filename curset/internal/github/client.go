@@ -2,23 +2,41 @@ package github
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 )
 
 const (
-	collectionURL = "https://raw.githubusercontent.com/bilgehannal/cursor-config/refs/heads/main/data/collection.json"
-	contentsAPI   = "https://api.github.com/repos/bilgehannal/cursor-config/contents/data/.cursor"
-	rawBaseURL    = "https://raw.githubusercontent.com/bilgehannal/cursor-config/refs/heads/main/data/.cursor"
+	// DefaultRepo is the repository curset fetches collections from.
+	DefaultRepo = "bilgehannal/cursor-config"
+	// DefaultRef is the branch used when no ref is given.
+	DefaultRef = "main"
+	// MaxFileSize is the largest file curset downloads (1 MiB). Rules and commands are
+	// small text files; anything bigger is refused rather than written to .cursor/.
+	MaxFileSize = 1 << 20
+
+	collectionURL = "https://raw.githubusercontent.com/%s/%s/data/collection.json"
+	signatureURL  = "https://raw.githubusercontent.com/%s/%s/data/collection.json.minisig"
+	contentsAPI   = "https://api.github.com/repos/%s/contents/data/.cursor"
+	commitsAPI    = "https://api.github.com/repos/%s/commits/%s"
+	repoURL       = "https://github.com/%s"
+	rawBaseURL    = "https://raw.githubusercontent.com/%s/%s/data/.cursor"
 )
 
+// ErrPathNotFound is returned by ListContents when the path doesn't exist.
+var ErrPathNotFound = errors.New("path not found")
+
 // ContentEntry represents a single entry from the GitHub Contents API.
 type ContentEntry struct {
 	Name        string `json:"name"`
 	Path        string `json:"path"`
-	Type        string `json:"type"` // "file" or "dir"
+	Type        string `json:"type"` // "file", "dir", "symlink" or "submodule"
 	DownloadURL string `json:"download_url"`
+	Size        int64  `json:"size"`
+	SHA         string `json:"sha"` // git blob SHA-1 of the file content
 }
 
 // ContentsResult holds the result of a GitHub Contents API call.
@@ -30,20 +48,120 @@ type ContentsResult struct {
 // Client is an HTTP client for fetching data from GitHub.
 type Client struct {
 	httpClient *http.Client
+	repo       string                     // "owner/repo"
+	ref        string                     // branch, tag or commit
 	cache      map[string]*ContentsResult // cache for ListContents results
+	commit     string                     // ref resolved to a commit SHA by ResolveRef
+	resolveErr error                      // why ResolveRef failed, so it is only tried once
 }
 
-// NewClient creates a new GitHub client.
+// NewClient creates a new GitHub client for the default repository and ref.
 func NewClient() *Client {
+	return NewClientFor(DefaultRepo, DefaultRef)
+}
+
+// NewClientFor creates a new GitHub client for the given repository ("owner/repo") and ref.
+// Empty values fall back to DefaultRepo and DefaultRef.
+func NewClientFor(repo, ref string) *Client {
+	if repo == "" {
+		repo = DefaultRepo
+	}
+	if ref == "" {
+		ref = DefaultRef
+	}
 	return &Client{
 		httpClient: &http.Client{},
+		repo:       repo,
+		ref:        ref,
 		cache:      make(map[string]*ContentsResult),
 	}
 }
 
+// Repo returns the repository the client fetches from.
+func (c *Client) Repo() string {
+	return c.repo
+}
+
+// Ref returns the branch, tag or commit the client fetches from.
+func (c *Client) Ref() string {
+	return c.ref
+}
+
+// URL returns the repository's web URL.
+func (c *Client) URL() string {
+	return fmt.Sprintf(repoURL, c.repo)
+}
+
+// ResolveRef resolves the client's ref to the commit SHA it currently points to.
+// The ref is resolved once; every later fetch reads that commit, so content fetched by
+// one client always matches the commit it reports even if the ref moves meanwhile.
+func (c *Client) ResolveRef() (string, error) {
+	if c.commit != "" || c.resolveErr != nil {
+		return c.commit, c.resolveErr
+	}
+	if isCommitSHA(c.ref) {
+		c.commit = c.ref
+		return c.commit, nil
+	}
+
+	c.commit, c.resolveErr = c.fetchCommit()
+	return c.commit, c.resolveErr
+}
+
+// fetchCommit asks the GitHub API which commit the ref points to.
+func (c *Client) fetchCommit() (string, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(commitsAPI, c.repo, c.ref), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github.sha")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", c.ref, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to resolve %s: HTTP %d", c.ref, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 128))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", c.ref, err)
+	}
+	commit := strings.TrimSpace(string(body))
+	if !isCommitSHA(commit) {
+		return "", fmt.Errorf("failed to resolve %s: unexpected response", c.ref)
+	}
+	return commit, nil
+}
+
+// fetchRef returns the ref to fetch content at: the commit the ref resolved to, or the
+// ref itself if it could not be resolved.
+func (c *Client) fetchRef() string {
+	if commit, err := c.ResolveRef(); err == nil {
+		return commit
+	}
+	return c.ref
+}
+
+// isCommitSHA reports whether ref is a full hex commit SHA.
+func isCommitSHA(ref string) bool {
+	if len(ref) != 40 {
+		return false
+	}
+	for _, r := range ref {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
 // FetchCollectionJSON fetches the collection.json from the raw GitHub URL and returns the bytes.
 func (c *Client) FetchCollectionJSON() ([]byte, error) {
-	resp, err := c.httpClient.Get(collectionURL)
+	resp, err := c.httpClient.Get(fmt.Sprintf(collectionURL, c.repo, c.fetchRef()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch collection.json: %w", err)
 	}
@@ -56,6 +174,26 @@ func (c *Client) FetchCollectionJSON() ([]byte, error) {
 	return io.ReadAll(resp.Body)
 }
 
+// FetchCollectionSignature fetches the detached minisign signature of collection.json
+// (data/collection.json.minisig).
+func (c *Client) FetchCollectionSignature() ([]byte, error) {
+	resp, err := c.httpClient.Get(fmt.Sprintf(signatureURL, c.repo, c.fetchRef()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch collection.json.minisig: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("collection.json is not signed (no collection.json.minisig in %s@%s)", c.repo, c.ref)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch collection.json.minisig: HTTP %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
 // ListContents lists the contents of a path under data/.cursor/ using the GitHub Contents API.
 // For example, path "rules/common" lists files in data/.cursor/rules/common/.
 // Returns ContentsResult which indicates whether the path is a directory or a file.
@@ -66,7 +204,7 @@ func (c *Client) ListContents(path string) (*ContentsResult, error) {
 		return cached, nil
 	}
 
-	url := fmt.Sprintf("%s/%s", contentsAPI, path)
+	url := fmt.Sprintf("%s/%s?ref=%s", fmt.Sprintf(contentsAPI, c.repo), path, c.fetchRef())
 
 	resp, err := c.httpClient.Get(url)
 	if err != nil {
@@ -75,7 +213,7 @@ func (c *Client) ListContents(path string) (*ContentsResult, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("path not found: %s", path)
+		return nil, fmt.Errorf("%w: %s", ErrPathNotFound, path)
 	}
 
 	if resp.StatusCode == http.StatusForbidden {
@@ -114,7 +252,7 @@ func (c *Client) ListContents(path string) (*ContentsResult, error) {
 // DownloadFile downloads a raw file from the repository.
 // The filePath is relative to data/.cursor/, e.g. "rules/common/clean-code.mdc".
 func (c *Client) DownloadFile(filePath string) ([]byte, error) {
-	url := fmt.Sprintf("%s/%s", rawBaseURL, filePath)
+	url := fmt.Sprintf("%s/%s", fmt.Sprintf(rawBaseURL, c.repo, c.fetchRef()), filePath)
 
 	resp, err := c.httpClient.Get(url)
 	if err != nil {
@@ -126,5 +264,12 @@ func (c *Client) DownloadFile(filePath string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to download %s: HTTP %d", filePath, resp.StatusCode)
 	}
 
-	return io.ReadAll(resp.Body)
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", filePath, err)
+	}
+	if len(data) > MaxFileSize {
+		return nil, fmt.Errorf("failed to download %s: larger than %d bytes", filePath, MaxFileSize)
+	}
+	return data, nil
 }
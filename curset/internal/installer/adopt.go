@@ -0,0 +1,257 @@
+package installer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/github"
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+)
+
+// Adopt records entries ("type/name") that already exist in the .cursor/ folder but are not
+// managed by curset in the manifest, as if they had been added, so that future updates manage
+// them. An entry is only adopted if its local files are identical to the source's, unless
+// force is set. Without refs, every unmanaged entry in the folder is considered, and those the
+// source doesn't have, such as the user's own rules, are skipped.
+// Returns an error if any entry could not be adopted.
+func (inst *Installer) Adopt(cf *collection.CollectionFile, refs []string, force bool) error {
+	all := len(refs) == 0
+	if all {
+		var err error
+		refs, err = inst.unmanaged()
+		if err != nil {
+			return err
+		}
+		if len(refs) == 0 {
+			fmt.Printf("No unmanaged entries in %s.\n", inst.root)
+			return nil
+		}
+	}
+
+	if err := inst.RecordEstimatedEntries(cf); err != nil {
+		return err
+	}
+
+	fmt.Printf("Adopting entries: %s\n\n", strings.Join(refs, ", "))
+
+	if err := inst.useChecksums(cf); err != nil {
+		return err
+	}
+	failed := 0
+	for _, ref := range refs {
+		objType, name, err := collection.SplitRef(ref)
+		if err != nil {
+			return err
+		}
+		if inst.manifest.IsManaged(objType, name) {
+			fmt.Printf("  skipped: %s (already managed by curset)\n", ref)
+			continue
+		}
+
+		if err := inst.adoptEntry(objType, cf.Lookup(objType, name), force); err != nil {
+			if all && errors.Is(err, source.ErrNotFound) {
+				fmt.Printf("  skipped: %s (not in the source)\n", ref)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "  error: %s: %v\n", ref, err)
+			failed++
+			continue
+		}
+		inst.manifest.AddRequested(ref)
+		if e := inst.manifest.GetEntry(objType, name); e != nil {
+			e.DependsOn = cf.Dependencies.Of(objType, name)
+		}
+	}
+
+	if err := inst.manifest.Save(); err != nil {
+		return fmt.Errorf("failed to save manifest: %w", err)
+	}
+
+	if failed > 0 {
+		fmt.Println("\nDone (with errors).")
+		return fmt.Errorf("%d entries could not be adopted", failed)
+	}
+
+	fmt.Println("\nDone.")
+	return nil
+}
+
+// adoptEntry compares the local files of an entry with its source and tracks them in the
+// manifest if they match or force is set.
+func (inst *Installer) adoptEntry(objType string, e collection.Entry, force bool) error {
+	src, err := inst.sourceFor(e)
+	if err != nil {
+		return err
+	}
+	remote, isDir, err := source.RemoteFiles(src, objType, e.Name)
+	if err != nil {
+		return err
+	}
+
+	local, err := inst.localFiles(objType, e.Name, isDir)
+	if err != nil {
+		return err
+	}
+	if len(local) == 0 {
+		return fmt.Errorf("not found in %s", inst.root)
+	}
+
+	// Compare file by file: everything the source has must exist locally with the same content.
+	var diffs []string
+	for _, rel := range sortedKeys(remote) {
+		localPath := filepath.Join(inst.root, rel)
+		want, err := inst.download(src, remote[rel])
+		if err != nil {
+			return fmt.Errorf("failed to download %s: %w", rel, err)
+		}
+		got, err := os.ReadFile(localPath)
+		switch {
+		case os.IsNotExist(err):
+			diffs = append(diffs, "missing: "+localPath)
+		case err != nil:
+			return fmt.Errorf("failed to read %s: %w", localPath, err)
+		case !bytes.Equal(got, want):
+			diffs = append(diffs, "modified: "+localPath)
+		}
+	}
+	for _, rel := range local {
+		if _, ok := remote[rel]; !ok {
+			diffs = append(diffs, "extra: "+filepath.Join(inst.root, rel))
+		}
+	}
+
+	localDir := filepath.Join(inst.root, objType, e.Name)
+	if !isDir {
+		localDir = filepath.Join(inst.root, local[0])
+	}
+	if len(diffs) > 0 {
+		for _, d := range diffs {
+			fmt.Printf("    %s\n", d)
+		}
+		if !force {
+			return fmt.Errorf("%s differs from %s in %d file(s); use --force to adopt it anyway", localDir, src.Repo(), len(diffs))
+		}
+		fmt.Printf("  adopted: %s (%d files, %d differ from the source)\n", localDir, len(local), len(diffs))
+	} else {
+		fmt.Printf("  adopted: %s (%d files)\n", localDir, len(local))
+	}
+
+	return inst.trackAs(manifest.OpAdopt, src, manifest.Entry{
+		Type:   objType,
+		Name:   e.Name,
+		IsDir:  isDir,
+		Files:  local,
+		Source: src.Repo(),
+		Ref:    src.Ref(),
+	})
+}
+
+// localFiles returns the files of the entry objType/name in the .cursor/ folder, relative to it:
+// everything below its directory, or the files named after it without extension.
+func (inst *Installer) localFiles(objType, name string, isDir bool) ([]string, error) {
+	var files []string
+
+	if !isDir {
+		entries, err := os.ReadDir(filepath.Join(inst.root, objType))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Join(inst.root, objType), err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() && strings.TrimSuffix(e.Name(), filepath.Ext(e.Name())) == name {
+				files = append(files, filepath.Join(objType, e.Name()))
+			}
+		}
+		return files, nil
+	}
+
+	localDir := filepath.Join(inst.root, objType, name)
+	err := filepath.WalkDir(localDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(inst.root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", localDir, err)
+	}
+	return files, nil
+}
+
+// unmanaged returns the entries ("type/name") in the .cursor/ folder that curset does not manage:
+// the folders and files (by name without extension) inside each object type folder.
+func (inst *Installer) unmanaged() ([]string, error) {
+	types, err := os.ReadDir(inst.root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", inst.root, err)
+	}
+
+	// Local paths of managed entries, which may be installed under another name.
+	managed := make(map[string]bool)
+	for _, e := range inst.manifest.Entries {
+		managed[filepath.Join(e.Type, e.Local())] = true
+		for _, f := range e.Files {
+			managed[f] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	var refs []string
+	for _, t := range types {
+		if !t.IsDir() || manifest.IsStateFile(t.Name()) {
+			continue
+		}
+		children, err := os.ReadDir(filepath.Join(inst.root, t.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Join(inst.root, t.Name()), err)
+		}
+		for _, c := range children {
+			if managed[filepath.Join(t.Name(), c.Name())] {
+				continue
+			}
+			name := c.Name()
+			if !c.IsDir() {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			ref := collection.Ref(t.Name(), name)
+			if seen[ref] || inst.manifest.IsManaged(t.Name(), name) {
+				continue
+			}
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	sort.Strings(refs)
+	return refs, nil
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]github.ContentEntry) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,217 @@
+package installer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+)
+
+// renameSuffix is appended to an entry's name when it is installed beside unmanaged
+// content with --rename, e.g. rules/go-curset.
+const renameSuffix = "-curset"
+
+// Snapshots saves content in, and restores it from, a snapshot of the .cursor/ folder. It keeps
+// content overwritten with --force, and brings it back when the entry is uninstalled.
+type Snapshots interface {
+	Add(id string, paths []string) error
+	RestorePaths(id string, paths []string) error
+}
+
+// SetConflictMode sets how entries are installed over existing content that curset does
+// not manage: manifest.ConflictForce, ConflictBackup or ConflictRename. By default ("")
+// such entries are skipped.
+func (inst *Installer) SetConflictMode(mode string) {
+	inst.conflict = mode
+}
+
+// SetSnapshot sets the snapshot taken before this run, which holds content overwritten with --force.
+func (inst *Installer) SetSnapshot(s Snapshots, id string) {
+	inst.snapshots, inst.snapshotID = s, id
+}
+
+// planConflict decides how to handle existing, unmanaged content at paths (relative to
+// .cursor/) that the entry objType/name would be installed over, according to the conflict
+// mode. names are the entry's own names in the objType folder: its directory, or each of its
+// files. It returns the conflict to record in the manifest and the name to install the entry
+// under, or a nil conflict if the entry should be skipped. Nothing is changed on disk yet; see
+// installOver.
+func (inst *Installer) planConflict(objType, name string, names, paths []string) (*manifest.Conflict, string, error) {
+	switch inst.conflict {
+	case manifest.ConflictForce:
+		// Snapshots only hold managed content, so applyConflict saves what is overwritten.
+		if inst.snapshots == nil || inst.snapshotID == "" {
+			return nil, "", fmt.Errorf("cannot overwrite %s: no snapshot to keep it in", strings.Join(paths, ", "))
+		}
+		return &manifest.Conflict{Mode: manifest.ConflictForce, Paths: paths, Snapshot: inst.snapshotID}, name, nil
+
+	case manifest.ConflictBackup:
+		backup := filepath.Join(manifest.BackupDir, time.Now().Format("20060102-150405"))
+		return &manifest.Conflict{Mode: manifest.ConflictBackup, Paths: paths, Backup: backup}, name, nil
+
+	case manifest.ConflictRename:
+		local := name + renameSuffix
+		for _, n := range names {
+			target := filepath.Join(objType, localFileName(n, name, local))
+			if _, err := os.Lstat(filepath.Join(inst.root, target)); err == nil {
+				return nil, "", fmt.Errorf("cannot install beside %s/%s: %s exists as well", objType, name, target)
+			}
+		}
+		return &manifest.Conflict{Mode: manifest.ConflictRename, Paths: paths}, local, nil
+	}
+
+	return nil, name, nil
+}
+
+// applyConflict moves the unmanaged content of a planned conflict out of the way: --force
+// saves it in the snapshot taken before this run and removes it, --backup moves it to the
+// backup folder, and --rename leaves it alone.
+func (inst *Installer) applyConflict(c *manifest.Conflict) error {
+	switch c.Mode {
+	case manifest.ConflictForce:
+		if err := inst.snapshots.Add(c.Snapshot, c.Paths); err != nil {
+			return err
+		}
+		for _, p := range c.Paths {
+			localPath := filepath.Join(inst.root, p)
+			if err := os.RemoveAll(localPath); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", localPath, err)
+			}
+			fmt.Printf("  overwriting: %s (kept in snapshot %s)\n", localPath, c.Snapshot)
+		}
+
+	case manifest.ConflictBackup:
+		for _, p := range c.Paths {
+			from, to := filepath.Join(inst.root, p), filepath.Join(inst.root, c.Backup, p)
+			if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(to), err)
+			}
+			if err := os.Rename(from, to); err != nil {
+				return fmt.Errorf("failed to back up %s: %w", from, err)
+			}
+			fmt.Printf("  backed up: %s -> %s\n", from, to)
+		}
+	}
+	return nil
+}
+
+// installOver installs an entry whose own paths (relative to .cursor/) are paths. install
+// writes the entry below base, a folder relative to .cursor/ that stands in for it, and returns
+// the written files relative to .cursor/. Without a conflict the entry is installed in place.
+// Over unmanaged content, it is installed into a staging folder first, and only once every
+// file was downloaded and verified is the conflict applied and the entry moved into place, so
+// a failed install leaves the unmanaged content untouched.
+func (inst *Installer) installOver(c *manifest.Conflict, paths []string, install func(base string) ([]string, error)) ([]string, error) {
+	if c == nil {
+		return install("")
+	}
+
+	staging, err := os.MkdirTemp(inst.root, manifest.StagingPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging folder: %w", err)
+	}
+	defer os.RemoveAll(staging)
+	base := filepath.Base(staging)
+
+	files, err := install(base)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range files {
+		if files[i], err = filepath.Rel(base, f); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := inst.applyConflict(c); err != nil {
+		return nil, err
+	}
+	for i, p := range paths {
+		from, to := filepath.Join(staging, p), filepath.Join(inst.root, p)
+		err := os.MkdirAll(filepath.Dir(to), 0755)
+		if err == nil {
+			err = os.Rename(from, to)
+		}
+		if err != nil {
+			// Put the unmanaged content back rather than leave it untracked in a backup or snapshot.
+			for _, moved := range paths[:i] {
+				os.RemoveAll(filepath.Join(inst.root, moved))
+			}
+			if err := inst.restoreConflict(c); err != nil {
+				fmt.Fprintf(os.Stderr, "  warning: %v\n", err)
+			}
+			return nil, fmt.Errorf("failed to move %s into place: %w", to, err)
+		}
+	}
+	return files, nil
+}
+
+// restoreConflict brings back the content an entry was installed over, after the entry's
+// own files have been removed.
+func (inst *Installer) restoreConflict(c *manifest.Conflict) error {
+	switch c.Mode {
+	case manifest.ConflictBackup:
+		for _, p := range c.Paths {
+			from, to := filepath.Join(inst.root, c.Backup, p), filepath.Join(inst.root, p)
+			if _, err := os.Lstat(to); err == nil {
+				return fmt.Errorf("cannot restore %s: it exists again (backup left in %s)", to, from)
+			}
+			if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+				return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(to), err)
+			}
+			if err := os.Rename(from, to); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", to, err)
+			}
+			fmt.Printf("  restored: %s\n", to)
+		}
+		removeEmptyDirs(filepath.Join(inst.root, manifest.BackupDir))
+
+	case manifest.ConflictForce:
+		if c.Snapshot == "" || inst.snapshots == nil {
+			return fmt.Errorf("cannot restore %s: no snapshot of the overwritten content", strings.Join(c.Paths, ", "))
+		}
+		if err := inst.snapshots.RestorePaths(c.Snapshot, c.Paths); err != nil {
+			return fmt.Errorf("cannot restore overwritten content: %w", err)
+		}
+		for _, p := range c.Paths {
+			fmt.Printf("  restored: %s (from snapshot %s)\n", filepath.Join(inst.root, p), c.Snapshot)
+		}
+	}
+	return nil
+}
+
+// localFileName returns the local name of the file fileName of entry when the entry is
+// installed as localName, e.g. "review.md" of "review" becomes "review-curset.md".
+func localFileName(fileName, entry, localName string) string {
+	if localName == entry || !strings.HasPrefix(fileName, entry) {
+		return fileName
+	}
+	return localName + strings.TrimPrefix(fileName, entry)
+}
+
+// removeEmptyDirs removes dir and the folders below it that contain no files.
+func removeEmptyDirs(dir string) {
+	var dirs []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	// Deepest first; removing a non-empty folder fails and leaves it in place.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		os.Remove(dirs[i])
+	}
+}
+
+// renamed returns the LocalName to record for an entry installed as localName.
+func renamed(entry, localName string) string {
+	if localName == entry {
+		return ""
+	}
+	return localName
+}
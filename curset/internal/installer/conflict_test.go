@@ -0,0 +1,232 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/github"
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+	"github.com/bilgehannal/cursor-config/curset/internal/snapshot"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+)
+
+// writeFiles creates files (path relative to dir -> content).
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readFile returns the content of path (relative to dir), or "" if it doesn't exist.
+func readFile(t *testing.T, dir, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+	if os.IsNotExist(err) {
+		return ""
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// newSource creates a local source whose data/.cursor/ holds files, with collection.json cf.
+func newSource(t *testing.T, cf string, files map[string]string) (*source.Local, *collection.CollectionFile) {
+	t.Helper()
+	root := t.TempDir()
+	writeFiles(t, filepath.Join(root, "data", ".cursor"), files)
+	writeFiles(t, filepath.Join(root, "data"), map[string]string{"collection.json": cf})
+	parsed, err := collection.Parse([]byte(cf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source.NewLocal(root), parsed
+}
+
+// newTestInstaller returns an installer for a new .cursor/ folder holding files curset doesn't
+// manage, with conflict mode mode and a snapshot taken before the run.
+func newTestInstaller(t *testing.T, src source.Source, mode string, files map[string]string) (*Installer, string) {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), ".cursor")
+	writeFiles(t, root, files)
+
+	inst, err := NewInstaller(src, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { inst.Close() })
+
+	store, err := snapshot.Open(t.TempDir(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := store.Take("curset install go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst.SetSnapshot(store, snap.ID)
+	inst.SetConflictMode(mode)
+	return inst, root
+}
+
+const goCollection = `{"collections": {"go": {"rules": ["go"], "commands": ["review"]}}}`
+
+var goFiles = map[string]string{
+	"rules/go/basic.mdc": "basic",
+	"commands/review.md": "review",
+}
+
+// unmanaged is the content that exists before the install, in the entries' place.
+var unmanaged = map[string]string{
+	"rules/go/mine.mdc":  "mine",
+	"commands/review.md": "my review",
+}
+
+func TestConflictModes(t *testing.T) {
+	tests := []struct {
+		mode string
+		want map[string]string // content after install, "" for missing
+	}{
+		{
+			mode: "",
+			want: map[string]string{"rules/go/mine.mdc": "mine", "rules/go/basic.mdc": "", "commands/review.md": "my review"},
+		},
+		{
+			mode: manifest.ConflictForce,
+			want: map[string]string{"rules/go/mine.mdc": "", "rules/go/basic.mdc": "basic", "commands/review.md": "review"},
+		},
+		{
+			mode: manifest.ConflictBackup,
+			want: map[string]string{"rules/go/mine.mdc": "", "rules/go/basic.mdc": "basic", "commands/review.md": "review"},
+		},
+		{
+			mode: manifest.ConflictRename,
+			want: map[string]string{
+				"rules/go/mine.mdc": "mine", "commands/review.md": "my review",
+				"rules/go-curset/basic.mdc": "basic", "commands/review-curset.md": "review",
+			},
+		},
+	}
+	for _, tt := range tests {
+		name := tt.mode
+		if name == "" {
+			name = "skip"
+		}
+		t.Run(name, func(t *testing.T) {
+			src, cf := newSource(t, goCollection, goFiles)
+			inst, root := newTestInstaller(t, src, tt.mode, unmanaged)
+
+			if err := inst.Install(cf, "go", collection.Filter{}); err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+			for path, want := range tt.want {
+				if got := readFile(t, root, path); got != want {
+					t.Errorf("%s = %q after install, want %q", path, got, want)
+				}
+			}
+			if tt.mode == "" {
+				if len(inst.manifest.Entries) != 0 {
+					t.Errorf("skipped entries are tracked: %v", inst.manifest.Entries)
+				}
+				return
+			}
+			if e := inst.manifest.GetEntry("rules", "go"); e == nil || e.Conflict == nil || e.Conflict.Mode != tt.mode {
+				t.Fatalf("rules/go entry = %+v, want a %s conflict recorded", e, tt.mode)
+			}
+
+			// Uninstalling brings the unmanaged content back.
+			if err := inst.Uninstall("go"); err != nil {
+				t.Fatalf("Uninstall() error = %v", err)
+			}
+			for path, want := range unmanaged {
+				if got := readFile(t, root, path); got != want {
+					t.Errorf("%s = %q after uninstall, want %q", path, got, want)
+				}
+			}
+			for _, path := range []string{"rules/go/basic.mdc", "rules/go-curset", "commands/review-curset.md", manifest.BackupDir} {
+				if _, err := os.Stat(filepath.Join(root, path)); !os.IsNotExist(err) {
+					t.Errorf("%s still exists after uninstall: %v", path, err)
+				}
+			}
+		})
+	}
+}
+
+func TestConflictRenameCollision(t *testing.T) {
+	src, cf := newSource(t, goCollection, goFiles)
+	files := map[string]string{"rules/go/mine.mdc": "mine", "rules/go-curset/other.mdc": "other"}
+	inst, root := newTestInstaller(t, src, manifest.ConflictRename, files)
+
+	if err := inst.Install(cf, "go", collection.Filter{Only: []string{"rules"}}); err == nil {
+		t.Fatal("Install() succeeded, want an error for the taken name")
+	}
+	for path, want := range files {
+		if got := readFile(t, root, path); got != want {
+			t.Errorf("%s = %q, want it untouched", path, got)
+		}
+	}
+}
+
+func TestConflictFailedInstall(t *testing.T) {
+	tests := []struct {
+		name  string
+		cf    string
+		files map[string]string
+	}{
+		{
+			name:  "oversized file in directory entry",
+			cf:    `{"collections": {"go": {"rules": ["go"]}}}`,
+			files: map[string]string{"rules/go/basic.mdc": "basic", "rules/go/zbig.mdc": strings.Repeat("x", github.MaxFileSize+1)},
+		},
+		{
+			name: "checksum mismatch in file entry",
+			cf: `{"collections": {"go": {"commands": ["review"]}},
+				"checksums": {"commands/review.md": "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}}`,
+			files: map[string]string{"commands/review.md": "review"},
+		},
+	}
+	for _, tt := range tests {
+		for _, mode := range []string{manifest.ConflictForce, manifest.ConflictBackup, manifest.ConflictRename} {
+			t.Run(tt.name+"/"+mode, func(t *testing.T) {
+				src, cf := newSource(t, tt.cf, tt.files)
+				inst, root := newTestInstaller(t, src, mode, unmanaged)
+
+				if err := inst.Install(cf, "go", collection.Filter{}); err == nil {
+					t.Fatal("Install() succeeded, want an error")
+				}
+				for path, want := range unmanaged {
+					if got := readFile(t, root, path); got != want {
+						t.Errorf("%s = %q after the failed install, want it untouched", path, got)
+					}
+				}
+				if len(inst.manifest.Entries) != 0 {
+					t.Errorf("failed entries are tracked: %v", inst.manifest.Entries)
+				}
+				entries, err := os.ReadDir(root)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, e := range entries {
+					if e.Name() == manifest.BackupDir || strings.HasPrefix(e.Name(), manifest.StagingPrefix) {
+						t.Errorf("%s left behind after the failed install", e.Name())
+					}
+				}
+				for _, path := range []string{"rules/go-curset", "commands/review-curset.md"} {
+					if _, err := os.Stat(filepath.Join(root, path)); !os.IsNotExist(err) {
+						t.Errorf("%s left behind after the failed install: %v", path, err)
+					}
+				}
+			})
+		}
+	}
+}
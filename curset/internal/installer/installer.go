@@ -1,49 +1,327 @@
 package installer
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/bilgehannal/cursor-config/curset/internal/collection"
 	"github.com/bilgehannal/cursor-config/curset/internal/github"
 	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
 )
 
-// Installer handles installing collections into the current directory.
+// Installer handles installing collections into a .cursor/ folder.
 type Installer struct {
-	client   *github.Client
+	root     string // the .cursor/ folder, e.g. ".cursor" or "~/.cursor"
+	source   source.Source
+	sources  map[string]source.Source // sources for entries overriding source or ref, keyed by "repo@ref"
+	link     bool                     // symlink entries from local sources instead of copying them
+	sums     collection.Checksums     // published checksums of the default source's files
+	signed   bool                     // sums are signed, so every download must match one
 	manifest *manifest.Manifest
+	lock     *manifest.Lock // held from NewInstaller until Close
+	version  string         // curset version recorded with installs
+	user     string         // user recorded with installs
+	commits  map[source.Source]string
+
+	conflict   string    // how to install over unmanaged content, see SetConflictMode
+	snapshots  Snapshots // restores content overwritten with --force
+	snapshotID string    // snapshot taken before this run
 }
 
-// NewInstaller creates a new Installer.
-func NewInstaller(client *github.Client) (*Installer, error) {
-	m, err := manifest.Load()
+// NewInstaller creates a new Installer that installs entries from src into the given .cursor/ folder.
+// It locks the folder against concurrent curset runs; call Close to release it.
+func NewInstaller(src source.Source, root string) (*Installer, error) {
+	lock, err := manifest.AcquireLock(root)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := manifest.Load(root)
 	if err != nil {
+		lock.Release()
 		return nil, fmt.Errorf("failed to load manifest: %w", err)
 	}
 
 	return &Installer{
-		client:   client,
+		root:     root,
+		source:   src,
+		sources:  make(map[string]source.Source),
 		manifest: m,
+		lock:     lock,
+		user:     manifest.CurrentUser(),
+		commits:  make(map[source.Source]string),
 	}, nil
 }
 
-// Install installs a named collection into the current directory's .cursor/ folder.
-func (inst *Installer) Install(col collection.Collection, name string) error {
-	fmt.Printf("Installing collection: %s\n\n", name)
+// SetVersion sets the curset version recorded in the manifest and history.
+func (inst *Installer) SetVersion(version string) {
+	inst.version = version
+}
+
+// Close releases the lock on the .cursor/ folder.
+func (inst *Installer) Close() error {
+	return inst.lock.Release()
+}
+
+// SetLink makes the installer symlink entries from local sources into the .cursor/ folder
+// instead of copying them, so edits in the source show up immediately.
+func (inst *Installer) SetLink(link bool) {
+	inst.link = link
+}
+
+// sourceFor returns the source to fetch an entry from.
+// Entries without a source or ref override use the installer's default source.
+func (inst *Installer) sourceFor(e collection.Entry) (source.Source, error) {
+	if !e.HasOverride() {
+		return inst.source, nil
+	}
+
+	src, err := source.ForEntry(inst.source, e)
+	if err != nil {
+		return nil, err
+	}
+	key := src.Repo() + "@" + src.Ref()
+	if cached, ok := inst.sources[key]; ok {
+		return cached, nil
+	}
+	inst.sources[key] = src
+	return src, nil
+}
+
+// ResetFilter forgets the filter a collection was installed with, so that installing it
+// again with an empty filter installs the full collection.
+func (inst *Installer) ResetFilter(name string) {
+	inst.manifest.SetFilter(name, collection.Filter{})
+}
+
+// HasEstimatedEntries reports whether the entries of an installed collection were estimated
+// when the manifest was upgraded from an older curset, rather than recorded at install time.
+func (inst *Installer) HasEstimatedEntries() bool {
+	return len(inst.manifest.EstimatedEntries) > 0
+}
+
+// RecordEstimatedEntries replaces the estimated entries of installed collections (see
+// HasEstimatedEntries) with the entries their filter selects in cf. Collections that cf no
+// longer defines keep their estimate until prune forgets them.
+func (inst *Installer) RecordEstimatedEntries(cf *collection.CollectionFile) error {
+	for _, name := range inst.manifest.EstimatedEntries {
+		col, ok := cf.Collections[name]
+		if !ok {
+			continue
+		}
+		resolved, err := cf.Dependencies.Expand(col)
+		if err != nil {
+			return err
+		}
+		filter := inst.manifest.Filter(name)
+		inst.manifest.SetEntriesOf(name, filter.Apply(col).Refs(), filter.Apply(resolved).Refs())
+	}
+	return nil
+}
+
+// Install installs a named collection from cf into the .cursor/ folder.
+// Dependencies of the collection's entries are installed as well. Only entries selected by
+// filter are installed; an empty filter reuses the one the collection was last installed with.
+// Managed entries that the filter no longer selects are removed unless still in use.
+func (inst *Installer) Install(cf *collection.CollectionFile, name string, filter collection.Filter) error {
+	if err := inst.useChecksums(cf); err != nil {
+		return err
+	}
+	col, err := cf.Expand(cf.Collections[name])
+	if err != nil {
+		return err
+	}
+
+	if filter.IsEmpty() {
+		filter = inst.manifest.Filter(name)
+	}
+
+	if err := inst.RecordEstimatedEntries(cf); err != nil {
+		return err
+	}
+
+	fmt.Printf("Installing collection: %s\n", name)
+	if !filter.IsEmpty() {
+		fmt.Printf("Filter: %s\n", filter)
+	}
+	fmt.Println()
 
 	inst.manifest.AddCollection(name)
+	inst.manifest.SetSourceOf(name, sourceOf(inst.source))
+	inst.manifest.SetFilter(name, filter)
+	inst.manifest.SetEntriesOf(name, filter.Apply(cf.Collections[name]).Refs(), filter.Apply(col).Refs())
+
+	// Entries skipped now may have been installed under an earlier filter.
+	dropped := make(collection.Collection)
+	for objType, entries := range col {
+		for _, e := range entries {
+			if !filter.Includes(objType, e.Name) && inst.manifest.IsManaged(objType, e.Name) {
+				dropped[objType] = append(dropped[objType], e)
+			}
+		}
+	}
+	if len(dropped) > 0 {
+		inUse, err := inst.inUse()
+		if err != nil {
+			return err
+		}
+		if err := inst.removeUnused(dropped, inUse); err != nil {
+			return err
+		}
+	}
+
+	return inst.installAll(filter.Apply(col), cf.Dependencies)
+}
 
+// Add installs individual entries ("type/name") without a collection.
+// They are tracked in the manifest as directly requested, together with their dependencies.
+func (inst *Installer) Add(cf *collection.CollectionFile, refs []string) error {
+	if err := inst.useChecksums(cf); err != nil {
+		return err
+	}
+	col, err := cf.Entries(refs)
+	if err != nil {
+		return err
+	}
+
+	col, err = cf.Expand(col)
+	if err != nil {
+		return err
+	}
+	if err := inst.RecordEstimatedEntries(cf); err != nil {
+		return err
+	}
+
+	fmt.Printf("Adding entries: %s\n\n", strings.Join(refs, ", "))
+
+	for _, ref := range refs {
+		inst.manifest.AddRequested(ref)
+	}
+	return inst.installAll(col, cf.Dependencies)
+}
+
+// Sync converges the .cursor/ folder to a declared state: the named collections of cf plus the
+// extra entries ("type/name"), minus the entries excluded by filter. Missing entries are installed,
+// existing ones updated, and managed entries that are no longer declared are removed.
+func (inst *Installer) Sync(cf *collection.CollectionFile, names, entries []string, filter collection.Filter) error {
+	if err := inst.useChecksums(cf); err != nil {
+		return err
+	}
+	extra, err := cf.Entries(entries)
+	if err != nil {
+		return err
+	}
+
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+	var all []collection.Collection
+	for _, name := range names {
+		all = append(all, cf.Collections[name])
+	}
+	// The collections come first, so that their entries win over the extra ones.
+	all = append(all, extra)
+
+	// The filter applies both to declared entries and to pulled-in dependencies.
+	desired, err := cf.Expand(filter.Apply(collection.Merge(all...)))
+	if err != nil {
+		return err
+	}
+	desired = filter.Apply(desired)
+
+	fmt.Printf("Syncing %s with %s\n\n", inst.root, strings.Join(append(names, entries...), ", "))
+
+	// Remove managed entries that are no longer declared.
+	keep := make(map[string]bool)
+	for objType, es := range desired {
+		for _, e := range es {
+			keep[collection.Ref(objType, e.Name)] = true
+		}
+	}
+	var stale []string
+	for _, e := range inst.manifest.Entries {
+		if ref := collection.Ref(e.Type, e.Name); !keep[ref] {
+			stale = append(stale, ref)
+		}
+	}
+	sort.Strings(stale)
+	for _, ref := range stale {
+		objType, name, _ := collection.SplitRef(ref)
+		if err := inst.removeEntry(objType, name); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", ref, err)
+		}
+	}
+
+	inst.manifest.Collections = names
+	inst.manifest.Filters = nil
+	inst.manifest.CollectionEntries = nil
+	inst.manifest.CollectionListed = nil
+	inst.manifest.CollectionSources = nil
+	inst.manifest.EstimatedEntries = nil
+	for _, name := range names {
+		inst.manifest.SetSourceOf(name, sourceOf(inst.source))
+		inst.manifest.SetFilter(name, filter)
+		col, err := cf.Expand(cf.Collections[name])
+		if err != nil {
+			return err
+		}
+		inst.manifest.SetEntriesOf(name, filter.Apply(cf.Collections[name]).Refs(), filter.Apply(col).Refs())
+	}
+	inst.manifest.Requested = nil
+	for _, ref := range entries {
+		objType, name, _ := collection.SplitRef(ref)
+		if filter.Includes(objType, name) {
+			inst.manifest.AddRequested(ref)
+		}
+	}
+
+	return inst.installAll(desired, cf.Dependencies)
+}
+
+// useChecksums sets the published checksums of cf that downloads are verified against. Linked
+// files aren't downloaded and follow the checkout as it changes, so they can't be held to signed
+// checksums: link mode is refused for a signed collection.json.
+func (inst *Installer) useChecksums(cf *collection.CollectionFile) error {
+	if inst.link && cf.Signed {
+		return fmt.Errorf("--link can't be used with a signed collection.json: linked files follow the checkout and can't be verified against its checksums")
+	}
+	inst.sums, inst.signed = cf.Checksums, cf.Signed
+	return nil
+}
+
+// refsToCollection groups "type/name" references into a collection.
+func refsToCollection(refs []string) (collection.Collection, error) {
+	col := make(collection.Collection)
+	for _, ref := range refs {
+		objType, name, err := collection.SplitRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		col[objType] = append(col[objType], collection.Entry{Name: name})
+	}
+	return col, nil
+}
+
+// installAll installs every entry of col, records dependencies and saves the manifest.
+func (inst *Installer) installAll(col collection.Collection, deps collection.Dependencies) error {
 	var installErr error
 	for objType, entries := range col {
 		for _, entry := range entries {
 			if err := inst.installEntry(objType, entry); err != nil {
-				fmt.Fprintf(os.Stderr, "  error: %s/%s: %v\n", objType, entry, err)
+				fmt.Fprintf(os.Stderr, "  error: %s/%s: %v\n", objType, entry.Name, err)
 				installErr = err
 				continue
 			}
+			if e := inst.manifest.GetEntry(objType, entry.Name); e != nil {
+				e.DependsOn = deps.Of(objType, entry.Name)
+			}
 		}
 	}
 
@@ -62,241 +340,776 @@ func (inst *Installer) Install(col collection.Collection, name string) error {
 }
 
 // installEntry installs a single entry (which may be a directory or a file).
-func (inst *Installer) installEntry(objType, entry string) error {
+func (inst *Installer) installEntry(objType string, e collection.Entry) error {
+	if err := collection.ValidateName(objType); err != nil {
+		return fmt.Errorf("unsafe object type: %w", err)
+	}
+	if err := collection.ValidateName(e.Name); err != nil {
+		return fmt.Errorf("unsafe entry name: %w", err)
+	}
+
+	src, err := inst.sourceFor(e)
+	if err != nil {
+		return err
+	}
+	inst.resolve(src) // pin the source to a commit before downloading from it
+	entry := e.Name
+
 	// Use GitHub Contents API to determine if this is a file or directory.
 	remotePath := fmt.Sprintf("%s/%s", objType, entry)
-	result, err := inst.client.ListContents(remotePath)
+	result, err := src.ListContents(remotePath)
 
 	if err != nil {
 		// If not found as a direct path, it might be a file without extension.
 		// List the parent directory and find matching files.
-		return inst.installFileByName(objType, entry)
+		return inst.installFileByName(src, objType, entry)
 	}
 
 	if result.IsDir {
 		// It's a directory - install all files in it.
-		return inst.installDirectory(objType, entry, result.Entries)
+		return inst.installDirectory(src, objType, entry, result.Entries)
 	}
 
 	// It's a single file.
-	return inst.installSingleFile(objType, result.Entries[0], entry)
+	return inst.installSingleFile(src, objType, result.Entries[0], entry)
 }
 
-// installDirectory installs all files from a remote directory.
-func (inst *Installer) installDirectory(objType, entry string, contents []github.ContentEntry) error {
-	localDir := filepath.Join(".cursor", objType, entry)
-	managed := inst.manifest.IsManaged(objType, entry)
+// installDirectory installs all files from a remote directory, including nested subdirectories.
+func (inst *Installer) installDirectory(src source.Source, objType, entry string, contents []github.ContentEntry) error {
+	localName, conflict := entry, (*manifest.Conflict)(nil)
+	previous := inst.manifest.GetEntry(objType, entry)
+	managed := previous != nil
+	if managed {
+		localName, conflict = previous.Local(), previous.Conflict
+	}
+	localDir := filepath.Join(inst.root, objType, localName)
 
 	// Check if directory already exists locally and is NOT managed by curset.
+	var pending *manifest.Conflict // resolved once the entry is downloaded, see installOver
 	if _, err := os.Stat(localDir); err == nil && !managed {
-		fmt.Printf("  skipped: %s (already exists, not managed by curset)\n", localDir)
-		return nil
+		c, name, err := inst.planConflict(objType, entry, []string{entry}, []string{filepath.Join(objType, entry)})
+		if err != nil {
+			return err
+		}
+		if c == nil {
+			fmt.Printf("  skipped: %s (already exists, not managed by curset)\n", localDir)
+			return nil
+		}
+		conflict, localName, pending = c, name, c
+		localDir = filepath.Join(inst.root, objType, localName)
 	}
 
 	if managed {
 		fmt.Printf("  updating: %s\n", localDir)
 	}
 
-	// Create the directory.
+	dir := filepath.Join(objType, localName)
+	installedFiles, err := inst.installOver(pending, []string{dir}, func(base string) ([]string, error) {
+		if inst.link {
+			return inst.linkDirectory(src, filepath.Join(objType, entry), filepath.Join(base, dir))
+		}
+		// Never write through a link left by an earlier linked install.
+		if err := removeLink(filepath.Join(inst.root, base, dir)); err != nil {
+			return nil, err
+		}
+		return inst.installTree(src, filepath.Join(objType, entry), filepath.Join(base, dir), contents)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Remove files left over from a previous version that no longer exist upstream.
+	if !inst.link && previous != nil && !previous.Linked {
+		if err := inst.removeStaleFiles(previous.Files, installedFiles); err != nil {
+			return err
+		}
+	}
+
+	action := "installed"
+	if managed {
+		action = "updated"
+	}
+	if inst.link {
+		fmt.Printf("  %s: %s (linked, %d files)\n", action, localDir, len(installedFiles))
+	} else {
+		fmt.Printf("  %s: %s (%d files)\n", action, localDir, len(installedFiles))
+	}
+
+	// Track in manifest.
+	return inst.track(src, manifest.Entry{
+		Type:      objType,
+		Name:      entry,
+		IsDir:     true,
+		Files:     installedFiles,
+		Source:    src.Repo(),
+		Ref:       src.Ref(),
+		Linked:    inst.link,
+		LocalName: renamed(entry, localName),
+		Conflict:  conflict,
+	})
+}
+
+// installTree downloads the files of a remote directory listing into the local directory dir,
+// descending into subdirectories of any depth. remoteDir is the listed directory and dir the one
+// it is installed to, both relative to .cursor/ (e.g. "rules/go"); they differ for renamed entries.
+// Returns the written file paths relative to .cursor/.
+func (inst *Installer) installTree(src source.Source, remoteDir, dir string, contents []github.ContentEntry) ([]string, error) {
+	// Check the whole listing first so a bad entry doesn't leave a half-written directory.
+	for _, c := range contents {
+		if err := source.CheckContent(c); err != nil {
+			return nil, err
+		}
+	}
+
+	localDir := filepath.Join(inst.root, dir)
 	if err := os.MkdirAll(localDir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory %s: %w", localDir, err)
+		return nil, fmt.Errorf("failed to create directory %s: %w", localDir, err)
 	}
 
 	var installedFiles []string
 	for _, c := range contents {
-		if c.Type != "file" {
-			continue
+		relPath := filepath.Join(dir, c.Name)
+		remotePath := filepath.Join(remoteDir, c.Name)
+
+		switch c.Type {
+		case "dir":
+			result, err := src.ListContents(filepath.ToSlash(remotePath))
+			if err != nil {
+				return nil, err
+			}
+			files, err := inst.installTree(src, remotePath, relPath, result.Entries)
+			if err != nil {
+				return nil, err
+			}
+			installedFiles = append(installedFiles, files...)
+		case "file":
+			if err := inst.writeFile(src, c, filepath.Join(inst.root, relPath)); err != nil {
+				return nil, err
+			}
+			installedFiles = append(installedFiles, relPath)
 		}
+	}
+
+	return installedFiles, nil
+}
+
+// writeFile installs the file c of src to localPath, by downloading it or, in link mode,
+// by symlinking it. Downloads are verified before anything is written.
+func (inst *Installer) writeFile(src source.Source, c github.ContentEntry, localPath string) error {
+	if err := source.CheckContent(c); err != nil {
+		return err
+	}
+	if c.Type != "file" {
+		return fmt.Errorf("refusing %s: not a file", c.Path)
+	}
+	if err := inst.checkInside(localPath); err != nil {
+		return err
+	}
 
-		remotePath := fmt.Sprintf("%s/%s/%s", objType, entry, c.Name)
-		data, err := inst.client.DownloadFile(remotePath)
+	// The c.Path is relative to the repo root (e.g. "data/.cursor/commands/file.md").
+	// We need the path relative to data/.cursor/.
+	remotePath := strings.TrimPrefix(c.Path, "data/.cursor/")
+
+	if inst.link {
+		local, err := localSource(src)
 		if err != nil {
 			return err
 		}
+		return replaceWithLink(local.Path(remotePath), localPath)
+	}
 
-		localPath := filepath.Join(localDir, c.Name)
-		if err := os.WriteFile(localPath, data, 0644); err != nil {
-			return fmt.Errorf("failed to write %s: %w", localPath, err)
-		}
-		installedFiles = append(installedFiles, filepath.Join(objType, entry, c.Name))
+	// Never write through a link left by an earlier linked install.
+	if err := removeLink(localPath); err != nil {
+		return err
 	}
 
-	if !managed {
-		fmt.Printf("  installed: %s (%d files)\n", localDir, len(installedFiles))
-	} else {
-		fmt.Printf("  updated: %s (%d files)\n", localDir, len(installedFiles))
+	data, err := inst.download(src, c)
+	if err != nil {
+		return fmt.Errorf("refusing to write %s: %w", localPath, err)
 	}
 
-	// Track in manifest.
-	inst.manifest.AddOrUpdate(manifest.Entry{
-		Type:  objType,
-		Name:  entry,
-		IsDir: true,
-		Files: installedFiles,
-	})
+	if err := os.WriteFile(localPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", localPath, err)
+	}
+	return nil
+}
+
+// download fetches the file c of src and verifies it against its listing and, for the
+// default source, the published checksum.
+func (inst *Installer) download(src source.Source, c github.ContentEntry) ([]byte, error) {
+	remotePath := strings.TrimPrefix(c.Path, "data/.cursor/")
+
+	data, err := src.DownloadFile(remotePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > github.MaxFileSize {
+		return nil, fmt.Errorf("larger than %d bytes", github.MaxFileSize)
+	}
+
+	// Published checksums describe the default source only.
+	expected := ""
+	if src == inst.source {
+		expected = inst.sums.Of(remotePath)
+	}
+	if inst.signed && expected == "" {
+		return nil, fmt.Errorf("not covered by the signed checksums of collection.json")
+	}
+	if err := verifyDownload(c, data, expected); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
 
+// checkInside returns an error unless path lies inside the .cursor/ folder.
+func (inst *Installer) checkInside(path string) error {
+	rel, err := filepath.Rel(inst.root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return fmt.Errorf("refusing %s: outside %s", path, inst.root)
+	}
 	return nil
 }
 
-// installSingleFile installs a single file that was found directly by path.
-func (inst *Installer) installSingleFile(objType string, entry github.ContentEntry, entryName string) error {
-	localDir := filepath.Join(".cursor", objType)
-	if err := os.MkdirAll(localDir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory %s: %w", localDir, err)
+// verifyDownload checks downloaded data against the size and git blob SHA reported by the
+// contents listing and, if not empty, the expected hex SHA-256 from collection.json.
+func verifyDownload(c github.ContentEntry, data []byte, expected string) error {
+	if c.Size > 0 && int64(len(data)) != c.Size {
+		return fmt.Errorf("size mismatch: expected %d bytes, got %d", c.Size, len(data))
 	}
 
-	localPath := filepath.Join(localDir, entry.Name)
-	managed := inst.manifest.IsManaged(objType, entryName)
+	if c.SHA != "" {
+		h := sha1.New()
+		fmt.Fprintf(h, "blob %d\x00", len(data))
+		h.Write(data)
+		if got := hex.EncodeToString(h.Sum(nil)); got != c.SHA {
+			return fmt.Errorf("git blob SHA mismatch: expected %s, got %s", c.SHA, got)
+		}
+	}
 
-	// Check if file already exists locally and is NOT managed by curset.
-	if _, err := os.Stat(localPath); err == nil && !managed {
-		fmt.Printf("  skipped: %s (already exists, not managed by curset)\n", localPath)
-		return nil
+	if expected != "" {
+		if got := manifest.Sum(data).SHA256; !strings.EqualFold(got, expected) {
+			return fmt.Errorf("checksum mismatch: expected sha256 %s, got %s", expected, got)
+		}
 	}
 
-	// The entry.Path is relative to the repo root (e.g. "data/.cursor/commands/file.md").
-	// We need the path relative to data/.cursor/.
-	relativePath := strings.TrimPrefix(entry.Path, "data/.cursor/")
-	data, err := inst.client.DownloadFile(relativePath)
-	if err != nil {
-		return err
+	return nil
+}
+
+// track records an entry installed from src in the manifest, with checksums of its copied
+// files and its provenance, and logs the install or update in the history.
+func (inst *Installer) track(src source.Source, e manifest.Entry) error {
+	op := manifest.OpInstall
+	if inst.manifest.IsManaged(e.Type, e.Name) {
+		op = manifest.OpUpdate
 	}
+	return inst.trackAs(op, src, e)
+}
 
-	if err := os.WriteFile(localPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write %s: %w", localPath, err)
+// trackAs is track with the operation to log in the history.
+func (inst *Installer) trackAs(op string, src source.Source, e manifest.Entry) error {
+	if !e.Linked {
+		e.Checksums = make(map[string]manifest.Checksum, len(e.Files))
+		for _, f := range e.Files {
+			sum, err := manifest.SumFile(filepath.Join(inst.root, f))
+			if err != nil {
+				return fmt.Errorf("failed to hash %s: %w", f, err)
+			}
+			e.Checksums[f] = sum
+		}
 	}
 
-	action := "installed"
-	if managed {
-		action = "updated"
+	now := time.Now().UTC()
+	e.SourceURL = src.URL()
+	e.ResolvedRef = inst.resolve(src)
+	e.InstalledAt, e.UpdatedAt = now, now
+	e.InstalledBy = inst.user
+	e.CursetVersion = inst.version
+
+	if previous := inst.manifest.GetEntry(e.Type, e.Name); previous != nil && !previous.InstalledAt.IsZero() {
+		e.InstalledAt = previous.InstalledAt
 	}
-	fmt.Printf("  %s: %s\n", action, localPath)
 
-	// Track in manifest.
-	inst.manifest.AddOrUpdate(manifest.Entry{
-		Type:  objType,
-		Name:  entryName,
-		IsDir: false,
-		Files: []string{filepath.Join(objType, entry.Name)},
+	inst.manifest.AddOrUpdate(e)
+	return inst.record(op, e)
+}
+
+// resolve returns the commit src points to, or "" if it can't be resolved.
+// Results are cached so each source is resolved once per run. A GitHub source fetches
+// everything at the commit it resolved to, so the commit recorded for an entry is the one
+// its content was downloaded from.
+func (inst *Installer) resolve(src source.Source) string {
+	if commit, ok := inst.commits[src]; ok {
+		return commit
+	}
+	commit, _ := src.ResolveRef()
+	inst.commits[src] = commit
+	return commit
+}
+
+// record appends an operation on e to the history log.
+func (inst *Installer) record(op string, e manifest.Entry) error {
+	return manifest.AppendHistory(inst.root, manifest.Event{
+		Time:          time.Now().UTC(),
+		Op:            op,
+		Entry:         collection.Ref(e.Type, e.Name),
+		Source:        e.Source,
+		Ref:           e.Ref,
+		ResolvedRef:   e.ResolvedRef,
+		User:          inst.user,
+		CursetVersion: inst.version,
+	})
+}
+
+// linkDirectory symlinks a directory of a local source (remoteDir is relative to data/.cursor/,
+// e.g. "rules/go") into the .cursor/ folder as dir. Returns the files it contains, relative to .cursor/.
+func (inst *Installer) linkDirectory(src source.Source, remoteDir, dir string) ([]string, error) {
+	local, err := localSource(src)
+	if err != nil {
+		return nil, err
+	}
+
+	target := local.Path(filepath.ToSlash(remoteDir))
+	var files []string
+	err = filepath.WalkDir(target, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			rel, err := filepath.Rel(target, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.Join(dir, rel))
+		}
+		return nil
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", target, err)
+	}
+
+	localDir := filepath.Join(inst.root, dir)
+	if err := os.MkdirAll(filepath.Dir(localDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", filepath.Dir(localDir), err)
+	}
+	if err := replaceWithLink(target, localDir); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// localSource returns src as a local checkout, or an error if it is a remote repository.
+func localSource(src source.Source) (*source.Local, error) {
+	local, ok := src.(*source.Local)
+	if !ok {
+		return nil, fmt.Errorf("--link requires a local source, but %s is a remote repository", src.Repo())
+	}
+	return local, nil
+}
+
+// replaceWithLink replaces whatever is at localPath with a symlink to target.
+func replaceWithLink(target, localPath string) error {
+	if err := os.RemoveAll(localPath); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", localPath, err)
+	}
+	if err := os.Symlink(target, localPath); err != nil {
+		return fmt.Errorf("failed to link %s: %w", localPath, err)
+	}
+	return nil
+}
+
+// removeLink removes localPath if it is a symlink.
+func removeLink(localPath string) error {
+	info, err := os.Lstat(localPath)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+	if err := os.Remove(localPath); err != nil {
+		return fmt.Errorf("failed to remove link %s: %w", localPath, err)
+	}
+	return nil
+}
+
+// removeStaleFiles removes previously installed files (relative to .cursor/) that are not in
+// current, along with any directories left empty by the removal.
+func (inst *Installer) removeStaleFiles(previous, current []string) error {
+	keep := make(map[string]bool, len(current))
+	for _, f := range current {
+		keep[f] = true
+	}
 
+	for _, f := range previous {
+		if keep[f] {
+			continue
+		}
+		localPath := filepath.Join(inst.root, f)
+		if err := inst.checkInside(localPath); err != nil {
+			return err
+		}
+		if err := os.Remove(localPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove file %s: %w", localPath, err)
+		}
+		fmt.Printf("  removed: %s\n", localPath)
+		inst.removeEmptyParents(filepath.Dir(f))
+	}
 	return nil
 }
 
+// removeEmptyParents removes dir (relative to .cursor/) and its parents while they are empty.
+// The object type directory (e.g. "rules") is never removed.
+func (inst *Installer) removeEmptyParents(dir string) {
+	for strings.Contains(dir, string(filepath.Separator)) {
+		if os.Remove(filepath.Join(inst.root, dir)) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// installSingleFile installs a single file that was found directly by path.
+func (inst *Installer) installSingleFile(src source.Source, objType string, entry github.ContentEntry, entryName string) error {
+	return inst.installFiles(src, objType, entryName, []github.ContentEntry{entry})
+}
+
 // installFileByName searches the parent directory for files matching the entry name
 // (without extension) and installs them.
-func (inst *Installer) installFileByName(objType, entry string) error {
+func (inst *Installer) installFileByName(src source.Source, objType, entry string) error {
 	// List the parent directory (e.g. "commands").
-	result, err := inst.client.ListContents(objType)
+	result, err := src.ListContents(objType)
 	if err != nil {
 		return fmt.Errorf("failed to list %s: %w", objType, err)
 	}
 
-	localDir := filepath.Join(".cursor", objType)
-	if err := os.MkdirAll(localDir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory %s: %w", localDir, err)
-	}
-
-	managed := inst.manifest.IsManaged(objType, entry)
-
-	found := false
-	var installedFiles []string
+	var matches []github.ContentEntry
 	for _, c := range result.Entries {
-		if c.Type != "file" {
+		if c.Type == "dir" {
 			continue
 		}
 
 		// Match by name without extension.
-		nameWithoutExt := strings.TrimSuffix(c.Name, filepath.Ext(c.Name))
-		if nameWithoutExt == entry {
-			localPath := filepath.Join(localDir, c.Name)
-
-			// Check if file already exists locally and is NOT managed by curset.
-			if _, err := os.Stat(localPath); err == nil && !managed {
-				fmt.Printf("  skipped: %s (already exists, not managed by curset)\n", localPath)
-				found = true
-				continue
-			}
+		if strings.TrimSuffix(c.Name, filepath.Ext(c.Name)) == entry {
+			matches = append(matches, c)
+		}
+	}
+
+	if len(matches) == 0 {
+		return fmt.Errorf("entry %s/%s not found in remote repository", objType, entry)
+	}
+
+	return inst.installFiles(src, objType, entry, matches)
+}
+
+// installFiles installs the files of a file entry into its object type folder.
+func (inst *Installer) installFiles(src source.Source, objType, entry string, files []github.ContentEntry) error {
+	localDir := filepath.Join(inst.root, objType)
+	if err := os.MkdirAll(localDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", localDir, err)
+	}
+
+	localName, conflict := entry, (*manifest.Conflict)(nil)
+	previous := inst.manifest.GetEntry(objType, entry)
+	managed := previous != nil
+	if managed {
+		localName, conflict = previous.Local(), previous.Conflict
+	}
 
-			relativePath := strings.TrimPrefix(c.Path, "data/.cursor/")
-			data, err := inst.client.DownloadFile(relativePath)
+	// Check if any of the files already exists locally and is NOT managed by curset.
+	var pending *manifest.Conflict // resolved once the entry is downloaded, see installOver
+	if !managed {
+		var names, existing []string
+		for _, c := range files {
+			names = append(names, c.Name)
+			if _, err := os.Stat(filepath.Join(localDir, c.Name)); err == nil {
+				existing = append(existing, filepath.Join(objType, c.Name))
+			}
+		}
+		if len(existing) > 0 {
+			c, name, err := inst.planConflict(objType, entry, names, existing)
 			if err != nil {
 				return err
 			}
-
-			if err := os.WriteFile(localPath, data, 0644); err != nil {
-				return fmt.Errorf("failed to write %s: %w", localPath, err)
+			if c == nil {
+				for _, f := range existing {
+					fmt.Printf("  skipped: %s (already exists, not managed by curset)\n", filepath.Join(inst.root, f))
+				}
+				return nil
 			}
+			conflict, localName, pending = c, name, c
+		}
+	}
 
-			action := "installed"
-			if managed {
-				action = "updated"
+	var paths []string
+	for _, c := range files {
+		paths = append(paths, filepath.Join(objType, localFileName(c.Name, entry, localName)))
+	}
+	installedFiles, err := inst.installOver(pending, paths, func(base string) ([]string, error) {
+		dir := filepath.Join(inst.root, base, objType)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+		var written []string
+		for i, c := range files {
+			if err := inst.writeFile(src, c, filepath.Join(inst.root, base, paths[i])); err != nil {
+				return nil, err
 			}
-			fmt.Printf("  %s: %s\n", action, localPath)
-
-			installedFiles = append(installedFiles, filepath.Join(objType, c.Name))
-			found = true
+			written = append(written, filepath.Join(base, paths[i]))
 		}
+		return written, nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if !found {
-		return fmt.Errorf("entry %s/%s not found in remote repository", objType, entry)
+	action := "installed"
+	if managed {
+		action = "updated"
+	}
+	if inst.link {
+		action = "linked"
+	}
+	for _, f := range installedFiles {
+		fmt.Printf("  %s: %s\n", action, filepath.Join(inst.root, f))
 	}
 
 	// Track in manifest.
-	inst.manifest.AddOrUpdate(manifest.Entry{
-		Type:  objType,
-		Name:  entry,
-		IsDir: false,
-		Files: installedFiles,
+	return inst.track(src, manifest.Entry{
+		Type:      objType,
+		Name:      entry,
+		IsDir:     false,
+		Files:     installedFiles,
+		Source:    src.Repo(),
+		Ref:       src.Ref(),
+		Linked:    inst.link,
+		LocalName: renamed(entry, localName),
+		Conflict:  conflict,
 	})
-
-	return nil
 }
 
 // Uninstall removes a collection's entries that are not shared with other installed collections.
-// allCollections is the full collection.json data used to check for shared entries.
-func (inst *Installer) Uninstall(colToRemove collection.Collection, name string, allCollections map[string]collection.Collection) error {
+// It works from the entries the manifest recorded for the collection at install time, so it
+// needs neither network access nor the current collection.json. Entries that were directly
+// requested or are still required by an installed entry outside the collection are kept.
+func (inst *Installer) Uninstall(name string) error {
 	if !inst.manifest.HasCollection(name) {
 		return fmt.Errorf("collection '%s' is not installed", name)
 	}
 
+	colToRemove, err := refsToCollection(inst.manifest.EntriesOf(name))
+	if err != nil {
+		return err
+	}
+
 	fmt.Printf("Uninstalling collection: %s\n\n", name)
 
-	// Build a set of entries used by OTHER installed collections (not the one being removed).
-	shared := make(map[string]bool) // key: "type/name"
-	for _, otherName := range inst.manifest.Collections {
-		if otherName == name {
-			continue
+	inst.manifest.RemoveCollection(name)
+	inUse, err := inst.inUse()
+	if err != nil {
+		return err
+	}
+
+	if err := inst.removeUnused(colToRemove, inUse); err != nil {
+		return err
+	}
+
+	if err := inst.manifest.Save(); err != nil {
+		return fmt.Errorf("failed to save manifest: %w", err)
+	}
+
+	fmt.Println("\nDone.")
+	return nil
+}
+
+// Remove removes directly requested entries ("type/name") that were installed with Add, along
+// with the dependencies recorded for them. Entries still used by an installed collection,
+// another requested entry or a dependency are kept.
+func (inst *Installer) Remove(refs []string) error {
+	for _, ref := range refs {
+		if !inst.manifest.IsRequested(ref) {
+			return fmt.Errorf("entry '%s' was not added directly (use 'curset uninstall' for collection entries)", ref)
 		}
-		otherCol, ok := allCollections[otherName]
-		if !ok {
-			continue
+	}
+
+	col, err := refsToCollection(inst.manifest.WithDependencies(refs))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Removing entries: %s\n\n", strings.Join(refs, ", "))
+
+	for _, ref := range refs {
+		inst.manifest.RemoveRequested(ref)
+	}
+	inUse, err := inst.inUse()
+	if err != nil {
+		return err
+	}
+
+	if err := inst.removeUnused(col, inUse); err != nil {
+		return err
+	}
+
+	if err := inst.manifest.Save(); err != nil {
+		return fmt.Errorf("failed to save manifest: %w", err)
+	}
+
+	fmt.Println("\nDone.")
+	return nil
+}
+
+// inUse returns the entries ("type/name") that still have an owner in the manifest,
+// mapped to the reason they are needed, e.g. "owned by collection devops".
+func (inst *Installer) inUse() (map[string]string, error) {
+	inUse := make(map[string]string)
+	for ref, owners := range inst.manifest.Owners() {
+		described := make([]string, len(owners))
+		for i, owner := range owners {
+			described[i] = manifest.DescribeOwner(owner)
 		}
-		for objType, entries := range otherCol {
-			for _, entry := range entries {
-				shared[objType+"/"+entry] = true
-			}
+		inUse[ref] = "owned by " + strings.Join(described, ", ")
+	}
+
+	return inUse, nil
+}
+
+// removeUnused removes the entries of col that are not in inUse.
+// Entries that a remaining installed entry depends on are kept as well.
+func (inst *Installer) removeUnused(col collection.Collection, inUse map[string]string) error {
+	for _, key := range inst.unused(col, inUse) {
+		objType, entry, _ := collection.SplitRef(key)
+		if err := inst.removeEntry(objType, entry); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", key, err)
 		}
 	}
 
-	// Remove entries that belong to this collection and are NOT shared.
-	for objType, entries := range colToRemove {
+	return nil
+}
+
+// unused returns the entries ("type/name") of col that are not in inUse and that no
+// remaining installed entry depends on, sorted, and reports the ones it keeps.
+func (inst *Installer) unused(col collection.Collection, inUse map[string]string) []string {
+	remove := make(map[string]bool) // key: "type/name"
+	for objType, entries := range col {
 		for _, entry := range entries {
-			key := objType + "/" + entry
-			if shared[key] {
-				fmt.Printf("  kept: %s/%s (used by another installed collection)\n", objType, entry)
+			key := collection.Ref(objType, entry.Name)
+			if reason, ok := inUse[key]; ok {
+				fmt.Printf("  kept: %s (%s)\n", key, reason)
 				continue
 			}
+			remove[key] = true
+		}
+	}
 
-			if err := inst.removeEntry(objType, entry); err != nil {
-				return fmt.Errorf("failed to remove %s/%s: %w", objType, entry, err)
+	// Keep entries that an entry staying installed still depends on.
+	// Keeping one entry may in turn keep its own dependencies, so repeat until stable.
+	for changed := true; changed; {
+		changed = false
+		for key := range remove {
+			objType, entry, _ := collection.SplitRef(key)
+			for _, dependent := range inst.manifest.Dependents(objType, entry) {
+				if remove[dependent] {
+					continue
+				}
+				fmt.Printf("  kept: %s (required by %s)\n", key, dependent)
+				delete(remove, key)
+				changed = true
+				break
 			}
 		}
 	}
 
-	// Update manifest.
-	inst.manifest.RemoveCollection(name)
+	keys := make([]string, 0, len(remove))
+	for key := range remove {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Prune removes managed entries that are no longer referenced: neither part of an installed
+// collection (as its collection.json currently defines it) nor added directly, nor required by
+// such an entry. Each collection is checked against the collection.json of the source it was
+// installed from, fetched with fetch. This catches entries dropped from a collection upstream
+// and collections removed from their collection.json altogether, which are forgotten as well.
+// Collections installed before sources were recorded are checked against the installer's
+// source, but never forgotten. With dryRun, the entries are only listed.
+func (inst *Installer) Prune(fetch FetchFunc, dryRun bool) error {
+	if dryRun {
+		fmt.Printf("Pruning %s (dry run)\n\n", inst.root)
+	} else {
+		fmt.Printf("Pruning %s\n\n", inst.root)
+	}
+
+	// Refresh the recorded entries of each installed collection from its collection.json,
+	// so entries dropped upstream are no longer counted as used.
+	files := newCollectionFiles(inst.source, fetch)
+	var gone []string
+	goneFrom := make(map[string]manifest.CollectionSource)
+	for _, name := range inst.manifest.Collections {
+		cf, from, known, err := files.of(inst.manifest, name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  warning: collection '%s' kept as recorded: %v\n", name, err)
+			continue
+		}
+		col, ok := cf.Collections[name]
+		if !ok {
+			if !known {
+				fmt.Printf("  kept: collection '%s' (not in collection.json of %s, which it may not have been installed from; reinstall it to record its source)\n", name, from)
+				continue
+			}
+			gone = append(gone, name)
+			goneFrom[name] = from
+			continue
+		}
+		filter := inst.manifest.Filter(name)
+		resolved, err := cf.Dependencies.Expand(col)
+		if err != nil {
+			return err
+		}
+		inst.manifest.SetEntriesOf(name, filter.Apply(col).Refs(), filter.Apply(resolved).Refs())
+	}
+	for _, name := range gone {
+		if dryRun {
+			fmt.Printf("  would forget: collection '%s' (no longer in collection.json of %s)\n", name, goneFrom[name])
+			continue
+		}
+		inst.manifest.RemoveCollection(name)
+		fmt.Printf("  forgot: collection '%s' (no longer in collection.json of %s)\n", name, goneFrom[name])
+	}
+
+	inUse, err := inst.inUse()
+	if err != nil {
+		return err
+	}
+
+	managed := make(collection.Collection)
+	for _, e := range inst.manifest.Entries {
+		if _, ok := inUse[collection.Ref(e.Type, e.Name)]; !ok {
+			managed[e.Type] = append(managed[e.Type], collection.Entry{Name: e.Name})
+		}
+	}
+
+	orphans := inst.unused(managed, inUse)
+	if len(orphans) == 0 && len(gone) == 0 {
+		fmt.Println("  nothing to prune")
+		fmt.Println("\nDone.")
+		return nil
+	}
+
+	for _, key := range orphans {
+		if dryRun {
+			fmt.Printf("  would remove: %s\n", key)
+			continue
+		}
+		objType, entry, _ := collection.SplitRef(key)
+		if err := inst.removeEntry(objType, entry); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", key, err)
+		}
+	}
+
+	if dryRun {
+		fmt.Println("\nDry run: nothing was changed.")
+		return nil
+	}
+
 	if err := inst.manifest.Save(); err != nil {
 		return fmt.Errorf("failed to save manifest: %w", err)
 	}
@@ -305,7 +1118,82 @@ func (inst *Installer) Uninstall(colToRemove collection.Collection, name string,
 	return nil
 }
 
-// removeEntry removes a single entry (file or directory) from the local .cursor/ folder.
+// Verify re-hashes the managed files of the .cursor/ folder and reports files that are missing,
+// modified since installation, or unexpected inside a managed directory.
+// Linked entries are skipped since their content lives in the source.
+// Returns an error if any problem was found.
+func (inst *Installer) Verify() error {
+	fmt.Printf("Verifying %s\n\n", inst.root)
+
+	problems := 0
+	for _, e := range inst.manifest.Entries {
+		ref := collection.Ref(e.Type, e.Name)
+		if e.Linked {
+			fmt.Printf("  skipped: %s (linked)\n", ref)
+			continue
+		}
+
+		known := make(map[string]bool, len(e.Files))
+		for _, f := range e.Files {
+			known[f] = true
+			localPath := filepath.Join(inst.root, f)
+
+			want, recorded := e.Checksums[f]
+			got, err := manifest.SumFile(localPath)
+			switch {
+			case os.IsNotExist(err):
+				fmt.Printf("  missing: %s\n", localPath)
+				problems++
+			case err != nil:
+				return fmt.Errorf("failed to hash %s: %w", localPath, err)
+			case !recorded:
+				fmt.Printf("  unverified: %s (no checksum recorded, reinstall to record one)\n", localPath)
+			case got != want:
+				fmt.Printf("  modified: %s\n", localPath)
+				problems++
+			}
+		}
+
+		if !e.IsDir {
+			continue
+		}
+		localDir := filepath.Join(inst.root, e.Type, e.Local())
+		err := filepath.WalkDir(localDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(inst.root, path)
+			if err != nil {
+				return err
+			}
+			if !known[rel] {
+				fmt.Printf("  unexpected: %s\n", path)
+				problems++
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scan %s: %w", localDir, err)
+		}
+	}
+
+	if problems > 0 {
+		fmt.Println()
+		return fmt.Errorf("%d problem(s) found in %s", problems, inst.root)
+	}
+
+	fmt.Printf("  ok: %d entries match the manifest\n", len(inst.manifest.Entries))
+	fmt.Println("\nDone.")
+	return nil
+}
+
+// removeEntry removes a single entry (file or directory) from the .cursor/ folder.
 func (inst *Installer) removeEntry(objType, entry string) error {
 	manifestEntry := inst.manifest.GetEntry(objType, entry)
 	if manifestEntry == nil {
@@ -314,14 +1202,21 @@ func (inst *Installer) removeEntry(objType, entry string) error {
 	}
 
 	if manifestEntry.IsDir {
-		localDir := filepath.Join(".cursor", objType, entry)
+		// RemoveAll removes a linked directory's symlink without touching the source.
+		localDir := filepath.Join(inst.root, objType, manifestEntry.Local())
+		if err := inst.checkInside(localDir); err != nil {
+			return err
+		}
 		if err := os.RemoveAll(localDir); err != nil {
 			return fmt.Errorf("failed to remove directory %s: %w", localDir, err)
 		}
 		fmt.Printf("  removed: %s\n", localDir)
 	} else {
 		for _, f := range manifestEntry.Files {
-			localPath := filepath.Join(".cursor", f)
+			localPath := filepath.Join(inst.root, f)
+			if err := inst.checkInside(localPath); err != nil {
+				return err
+			}
 			if err := os.Remove(localPath); err != nil && !os.IsNotExist(err) {
 				return fmt.Errorf("failed to remove file %s: %w", localPath, err)
 			}
@@ -329,6 +1224,14 @@ func (inst *Installer) removeEntry(objType, entry string) error {
 		}
 	}
 
+	removed := *manifestEntry
 	inst.manifest.RemoveEntry(objType, entry)
-	return nil
+
+	// Bring back what the entry was installed over.
+	if removed.Conflict != nil {
+		if err := inst.restoreConflict(removed.Conflict); err != nil {
+			fmt.Fprintf(os.Stderr, "  warning: %v\n", err)
+		}
+	}
+	return inst.record(manifest.OpUninstall, removed)
 }
@@ -0,0 +1,323 @@
+package installer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/github"
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+)
+
+func TestCheckInside(t *testing.T) {
+	root := filepath.Join(t.TempDir(), ".cursor")
+	inst := &Installer{root: root}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "file in type folder", path: filepath.Join(root, "rules", "go.mdc")},
+		{name: "nested file", path: filepath.Join(root, "rules", "go", "testing", "table.mdc")},
+		{name: "name starting with dots", path: filepath.Join(root, "rules", "..hidden")},
+		{name: "root itself", path: root, wantErr: true},
+		{name: "parent", path: filepath.Dir(root), wantErr: true},
+		{name: "sibling", path: filepath.Join(filepath.Dir(root), ".bashrc"), wantErr: true},
+		{name: "escaping with dot-dot", path: root + string(filepath.Separator) + filepath.Join("rules", "..", "..", "x"), wantErr: true},
+		{name: "prefix of another folder", path: root + "-other" + string(filepath.Separator) + "x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := inst.checkInside(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkInside(%s) error = %v, want error: %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyDownload(t *testing.T) {
+	data := []byte("# Go\n")
+	const (
+		blobSHA = "452b575bcd2dc65a71594cc90a9a1c398de713d0"                         // git hash-object
+		digest  = "13ad8e753213f8ac6d0e7917677a03eac600790d616194982eb3c3040ab07665" // sha256sum
+		other   = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" // sha256 of nothing
+	)
+
+	tests := []struct {
+		name     string
+		entry    github.ContentEntry
+		data     []byte
+		expected string
+		wantErr  string
+	}{
+		{name: "no metadata", entry: github.ContentEntry{}, data: data},
+		{name: "matching size and blob SHA", entry: github.ContentEntry{Size: int64(len(data)), SHA: blobSHA}, data: data},
+		{name: "matching checksum", entry: github.ContentEntry{SHA: blobSHA}, data: data, expected: digest},
+		{name: "checksum in upper case", entry: github.ContentEntry{}, data: data, expected: strings.ToUpper(digest)},
+		{name: "size mismatch", entry: github.ContentEntry{Size: 100}, data: data, wantErr: "size mismatch"},
+		{name: "blob SHA mismatch", entry: github.ContentEntry{SHA: "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391"}, data: data, wantErr: "git blob SHA mismatch"},
+		{name: "checksum mismatch", entry: github.ContentEntry{}, data: data, expected: other, wantErr: "checksum mismatch"},
+		{name: "modified content", entry: github.ContentEntry{Size: int64(len(data)), SHA: blobSHA}, data: []byte("# Ga\n"), wantErr: "git blob SHA mismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyDownload(tt.entry, tt.data, tt.expected)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("verifyDownload() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("verifyDownload() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fetch reads and parses the collection.json of src.
+func fetch(src source.Source) (*collection.CollectionFile, error) {
+	data, err := src.FetchCollectionJSON()
+	if err != nil {
+		return nil, err
+	}
+	return collection.Parse(data)
+}
+
+const teamCollection = `{"collections": {"team": {"rules": ["go", "redis"]}}}`
+
+var teamFiles = map[string]string{
+	"rules/go/basic.mdc":    "go",
+	"rules/redis/basic.mdc": "redis",
+}
+
+// installTeam installs the collection "team" from a new fork into a new .cursor/ folder,
+// and returns the fork and the folder.
+func installTeam(t *testing.T) (*source.Local, string) {
+	t.Helper()
+	fork, cf := newSource(t, teamCollection, teamFiles)
+	root := filepath.Join(t.TempDir(), ".cursor")
+	inst, err := NewInstaller(fork, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inst.Close()
+	if err := inst.Install(cf, "team", collection.Filter{}); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	return fork, root
+}
+
+func TestPrune(t *testing.T) {
+	other, _ := newSource(t, `{"collections": {"devops": {"rules": ["go"]}}}`, teamFiles)
+
+	tests := []struct {
+		name       string
+		upstream   string                     // the fork's collection.json at prune time
+		prepare    func(m *manifest.Manifest) // changes the manifest before pruning
+		useOther   bool                       // prune with another --source
+		dryRun     bool
+		wantTeam   bool     // collection still installed
+		wantKept   []string // entries still installed
+		wantPruned []string // entries removed
+	}{
+		{
+			name:       "entry dropped upstream",
+			upstream:   `{"collections": {"team": {"rules": ["go"]}}}`,
+			wantTeam:   true,
+			wantKept:   []string{"rules/go"},
+			wantPruned: []string{"rules/redis"},
+		},
+		{
+			name:       "collection removed from its source",
+			upstream:   `{"collections": {}}`,
+			wantPruned: []string{"rules/go", "rules/redis"},
+		},
+		{
+			name:     "dry run",
+			upstream: `{"collections": {}}`,
+			dryRun:   true,
+			wantTeam: true,
+			wantKept: []string{"rules/go", "rules/redis"},
+		},
+		{
+			name:     "collection missing from another source",
+			upstream: teamCollection,
+			useOther: true,
+			wantTeam: true,
+			wantKept: []string{"rules/go", "rules/redis"},
+		},
+		{
+			name:       "another source doesn't hide upstream drops",
+			upstream:   `{"collections": {"team": {"rules": ["redis"]}}}`,
+			useOther:   true,
+			wantTeam:   true,
+			wantKept:   []string{"rules/redis"},
+			wantPruned: []string{"rules/go"},
+		},
+		{
+			name:     "unrecorded source",
+			upstream: teamCollection,
+			prepare:  func(m *manifest.Manifest) { m.CollectionSources = nil },
+			useOther: true,
+			wantTeam: true,
+			wantKept: []string{"rules/go", "rules/redis"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fork, root := installTeam(t)
+			writeFiles(t, filepath.Join(fork.Repo(), "data"), map[string]string{"collection.json": tt.upstream})
+
+			var src source.Source = fork
+			if tt.useOther {
+				src = other
+			}
+			inst, err := NewInstaller(src, root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer inst.Close()
+			if tt.prepare != nil {
+				tt.prepare(inst.manifest)
+			}
+
+			if err := inst.Prune(fetch, tt.dryRun); err != nil {
+				t.Fatalf("Prune() error = %v", err)
+			}
+			m, err := manifest.Load(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.HasCollection("team") != tt.wantTeam {
+				t.Errorf("collection team installed = %v, want %v", m.HasCollection("team"), tt.wantTeam)
+			}
+			for _, ref := range tt.wantKept {
+				objType, name, _ := collection.SplitRef(ref)
+				if !m.IsManaged(objType, name) || readFile(t, root, ref+"/basic.mdc") == "" {
+					t.Errorf("%s was pruned, want it kept", ref)
+				}
+			}
+			for _, ref := range tt.wantPruned {
+				objType, name, _ := collection.SplitRef(ref)
+				if m.IsManaged(objType, name) || readFile(t, root, ref+"/basic.mdc") != "" {
+					t.Errorf("%s was kept, want it pruned", ref)
+				}
+			}
+		})
+	}
+}
+
+func TestOutdatedCollectionSource(t *testing.T) {
+	other, _ := newSource(t, `{"collections": {"devops": {"rules": ["go"]}}}`, teamFiles)
+
+	tests := []struct {
+		name         string
+		upstream     string
+		prepare      func(m *manifest.Manifest)
+		want         string
+		wantOutdated bool
+	}{
+		{name: "unchanged in its source", upstream: teamCollection, want: StatusCurrent},
+		{name: "entry dropped in its source", upstream: `{"collections": {"team": {"rules": ["go"]}}}`, want: StatusOutdated, wantOutdated: true},
+		{name: "removed from its source", upstream: `{"collections": {}}`, want: StatusRemoved, wantOutdated: true},
+		{name: "unrecorded source", upstream: teamCollection, prepare: func(m *manifest.Manifest) { m.CollectionSources = nil }, want: StatusUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fork, root := installTeam(t)
+			writeFiles(t, filepath.Join(fork.Repo(), "data"), map[string]string{"collection.json": tt.upstream})
+			m, err := manifest.Load(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.prepare != nil {
+				tt.prepare(m)
+			}
+
+			// Checked with another --source, which must not count as the collection's source.
+			report, err := Outdated(other, m, fetch)
+			if err != nil {
+				t.Fatalf("Outdated() error = %v", err)
+			}
+			if len(report.Collections) != 1 || report.Collections[0].Status != tt.want {
+				t.Fatalf("collections = %+v, want team %s", report.Collections, tt.want)
+			}
+			if report.IsOutdated() != tt.wantOutdated {
+				t.Errorf("IsOutdated() = %v, want %v", report.IsOutdated(), tt.wantOutdated)
+			}
+		})
+	}
+}
+
+func TestAdopt(t *testing.T) {
+	fork, cf := newSource(t, teamCollection, teamFiles)
+	tests := []struct {
+		name    string
+		refs    []string
+		wantErr bool
+		want    []string // entries managed afterwards
+	}{
+		{name: "all unmanaged entries", want: []string{"rules/go"}},
+		{name: "named entry missing from the source", refs: []string{"rules/mine"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := filepath.Join(t.TempDir(), ".cursor")
+			writeFiles(t, root, map[string]string{
+				"rules/go/basic.mdc":  "go",
+				"rules/mine.mdc":      "mine",
+				"extensions/big.vsix": "extension",
+			})
+			inst, err := NewInstaller(fork, root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer inst.Close()
+
+			err = inst.Adopt(cf, tt.refs, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Adopt() error = %v, want error: %v", err, tt.wantErr)
+			}
+			m, err := manifest.Load(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range m.Entries {
+				got = append(got, e.Type+"/"+e.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("managed entries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkRefusedWhenSigned(t *testing.T) {
+	fork, cf := newSource(t, teamCollection, teamFiles)
+	cf.Signed = true
+	root := filepath.Join(t.TempDir(), ".cursor")
+	inst, err := NewInstaller(fork, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inst.Close()
+	inst.SetLink(true)
+
+	for name, run := range map[string]func() error{
+		"install": func() error { return inst.Install(cf, "team", collection.Filter{}) },
+		"add":     func() error { return inst.Add(cf, []string{"rules/go"}) },
+	} {
+		if err := run(); err == nil || !strings.Contains(err.Error(), "signed") {
+			t.Errorf("%s with --link error = %v, want it refused", name, err)
+		}
+	}
+	if got := readFile(t, root, "rules/go/basic.mdc"); got != "" {
+		t.Errorf("rules/go/basic.mdc = %q, want nothing installed", got)
+	}
+}
@@ -0,0 +1,226 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+)
+
+// Statuses of installed collections and entries compared with their source.
+const (
+	StatusCurrent  = "up to date"
+	StatusOutdated = "outdated"
+	StatusRemoved  = "removed upstream"
+	StatusLinked   = "linked"         // follows the local source, so it can't fall behind
+	StatusUnknown  = "unknown source" // installed before sources were recorded and not in the current collection.json
+)
+
+// EntryStatus compares an installed entry with its source.
+type EntryStatus struct {
+	Entry   string // "type/name"
+	Source  string // "repo@ref" the entry was installed from
+	Current string // commit installed, empty if not recorded
+	Latest  string // commit the ref points to now
+	Status  string
+}
+
+// CollectionStatus compares an installed collection with collection.json.
+type CollectionStatus struct {
+	Name     string
+	Added    []string // entries collection.json selects now that were not installed with it
+	Dropped  []string // installed entries collection.json no longer selects
+	Outdated []string // installed entries of the collection that are outdated or removed upstream
+	Status   string
+}
+
+// OutdatedReport lists the installed collections and entries of a .cursor/ folder with
+// their status against the latest upstream.
+type OutdatedReport struct {
+	Collections []CollectionStatus
+	Entries     []EntryStatus
+}
+
+// IsOutdated reports whether anything in the report is behind its source. Collections of
+// an unknown source don't count, since nothing says they are behind.
+func (r *OutdatedReport) IsOutdated() bool {
+	for _, c := range r.Collections {
+		if c.Status == StatusOutdated || c.Status == StatusRemoved {
+			return true
+		}
+	}
+	for _, e := range r.Entries {
+		if e.Status == StatusOutdated || e.Status == StatusRemoved {
+			return true
+		}
+	}
+	return false
+}
+
+// Outdated compares every entry installed in m with the source and ref it was installed
+// from, and every installed collection with the collection.json of the source it was installed
+// from, fetched with fetch. src stands in for entries and collections without a recorded
+// source. The manifest is only read, so the folder needs no lock. An entry is outdated when its
+// files differ from the latest upstream content; when its ref still points to the commit it was
+// installed from, nothing is downloaded. A collection is outdated when its collection.json now
+// selects other entries, or when any of its entries is outdated.
+func Outdated(src source.Source, m *manifest.Manifest, fetch FetchFunc) (*OutdatedReport, error) {
+	c := &checker{source: src, sources: make(map[string]source.Source)}
+	report := &OutdatedReport{}
+
+	status := make(map[string]string)
+	for i := range m.Entries {
+		st, err := c.entryStatus(&m.Entries[i])
+		if err != nil {
+			return nil, err
+		}
+		status[st.Entry] = st.Status
+		report.Entries = append(report.Entries, st)
+	}
+	sort.Slice(report.Entries, func(i, j int) bool { return report.Entries[i].Entry < report.Entries[j].Entry })
+
+	files := newCollectionFiles(src, fetch)
+	for _, name := range m.Collections {
+		st := CollectionStatus{Name: name, Status: StatusCurrent}
+		recorded := m.EntriesOf(name)
+		for _, ref := range recorded {
+			if s := status[ref]; s == StatusOutdated || s == StatusRemoved {
+				st.Outdated = append(st.Outdated, ref)
+			}
+		}
+
+		cf, _, known, err := files.of(m, name)
+		if err != nil {
+			return nil, err
+		}
+		col, ok := cf.Collections[name]
+		if !ok {
+			st.Status = StatusRemoved
+			if !known {
+				st.Status = StatusUnknown
+			}
+			report.Collections = append(report.Collections, st)
+			continue
+		}
+		col, err = cf.Dependencies.Expand(col)
+		if err != nil {
+			return nil, err
+		}
+		latest := m.Filter(name).Apply(col).Refs()
+		st.Added, st.Dropped = difference(latest, recorded), difference(recorded, latest)
+
+		if len(st.Added) > 0 || len(st.Dropped) > 0 || len(st.Outdated) > 0 {
+			st.Status = StatusOutdated
+		}
+		report.Collections = append(report.Collections, st)
+	}
+
+	return report, nil
+}
+
+// checker looks up the sources of installed entries for Outdated, opening each once.
+type checker struct {
+	source  source.Source            // for entries without a recorded source
+	sources map[string]source.Source // keyed by "repo@ref"
+}
+
+// entryStatus compares an installed entry with the latest content of its source.
+func (c *checker) entryStatus(e *manifest.Entry) (EntryStatus, error) {
+	src := c.sourceOf(e)
+	st := EntryStatus{
+		Entry:   collection.Ref(e.Type, e.Name),
+		Source:  src.Repo(),
+		Current: e.ResolvedRef,
+	}
+	if src.Ref() != "" {
+		st.Source += "@" + src.Ref()
+	}
+	if e.Linked {
+		st.Status = StatusLinked
+		return st, nil
+	}
+
+	st.Latest, _ = src.ResolveRef()
+	// A local checkout can change without a new commit, so its content is always compared.
+	if st.Current != "" && st.Current == st.Latest && !source.IsLocal(src.Repo()) {
+		st.Status = StatusCurrent
+		return st, nil
+	}
+
+	files, _, err := source.RemoteFiles(src, e.Type, e.Name)
+	if errors.Is(err, source.ErrNotFound) {
+		st.Status = StatusRemoved
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("failed to check %s: %w", st.Entry, err)
+	}
+
+	st.Status = StatusCurrent
+	if len(files) != len(e.Files) {
+		st.Status = StatusOutdated
+		return st, nil
+	}
+	for remote := range files {
+		want, ok := e.Checksums[installedPath(e, remote)]
+		if !ok {
+			st.Status = StatusOutdated
+			return st, nil
+		}
+		data, err := src.DownloadFile(filepath.ToSlash(remote))
+		if err != nil {
+			return st, fmt.Errorf("failed to check %s: %w", st.Entry, err)
+		}
+		if manifest.Sum(data) != want {
+			st.Status = StatusOutdated
+			return st, nil
+		}
+	}
+	return st, nil
+}
+
+// sourceOf returns the source an installed entry was fetched from.
+func (c *checker) sourceOf(e *manifest.Entry) source.Source {
+	if e.Source == "" {
+		return c.source
+	}
+	src := source.Open(e.Source, e.Ref)
+	key := src.Repo() + "@" + src.Ref()
+	if cached, ok := c.sources[key]; ok {
+		return cached
+	}
+	c.sources[key] = src
+	return src
+}
+
+// installedPath returns where the file remote of e (relative to .cursor/, e.g.
+// "rules/go/basic.mdc") is installed, taking a --rename local name into account.
+func installedPath(e *manifest.Entry, remote string) string {
+	objType, rest, _ := strings.Cut(filepath.ToSlash(remote), "/")
+	if e.IsDir {
+		rest = e.Local() + strings.TrimPrefix(rest, e.Name)
+	} else {
+		rest = localFileName(rest, e.Name, e.Local())
+	}
+	return filepath.Join(objType, rest)
+}
+
+// difference returns the refs of a that are not in b.
+func difference(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, ref := range b {
+		in[ref] = true
+	}
+	var out []string
+	for _, ref := range a {
+		if !in[ref] {
+			out = append(out, ref)
+		}
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package installer
+
+import (
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+)
+
+// FetchFunc fetches and parses the collection.json of a source.
+type FetchFunc func(src source.Source) (*collection.CollectionFile, error)
+
+// collectionFiles fetches the collection.json that installed collections were installed
+// from, once per source.
+type collectionFiles struct {
+	def   source.Source // for collections without a recorded source
+	fetch FetchFunc
+	files map[string]*collection.CollectionFile // keyed by "repo@ref"
+	errs  map[string]error
+}
+
+// newCollectionFiles returns a collectionFiles that reads collections without a recorded
+// source from def.
+func newCollectionFiles(def source.Source, fetch FetchFunc) *collectionFiles {
+	return &collectionFiles{
+		def:   def,
+		fetch: fetch,
+		files: make(map[string]*collection.CollectionFile),
+		errs:  make(map[string]error),
+	}
+}
+
+// of returns the collection.json the installed collection name was installed from, and the
+// source it was read from. known is false for collections installed before sources were
+// recorded; their collection.json is the default source's, which may not be theirs.
+func (c *collectionFiles) of(m *manifest.Manifest, name string) (cf *collection.CollectionFile, from manifest.CollectionSource, known bool, err error) {
+	src := c.def
+	from, known = m.SourceOf(name)
+	if !known {
+		from = sourceOf(c.def)
+	} else if from != sourceOf(c.def) {
+		src = source.Open(from.Source, from.Ref)
+	}
+
+	key := from.String()
+	if cf, ok := c.files[key]; ok {
+		return cf, from, known, nil
+	}
+	if err, ok := c.errs[key]; ok {
+		return nil, from, known, err
+	}
+	cf, err = c.fetch(src)
+	if err != nil {
+		c.errs[key] = err
+		return nil, from, known, err
+	}
+	c.files[key] = cf
+	return cf, from, known, nil
+}
+
+// sourceOf returns the manifest record of src.
+func sourceOf(src source.Source) manifest.CollectionSource {
+	return manifest.CollectionSource{Source: src.Repo(), Ref: src.Ref()}
+}
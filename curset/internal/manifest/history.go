@@ -0,0 +1,98 @@
+package manifest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+// HistoryFileName is the append-only log of operations inside a .cursor/ folder,
+// one JSON event per line.
+const HistoryFileName = ".curset-history.jsonl"
+
+// Operations recorded in the history.
+const (
+	OpInstall   = "install"
+	OpUpdate    = "update"
+	OpUninstall = "uninstall"
+	OpAdopt     = "adopt"
+)
+
+// Event is a single operation on an entry.
+type Event struct {
+	Time          time.Time `json:"time"`
+	Op            string    `json:"op"`    // OpInstall, OpUpdate, OpUninstall or OpAdopt
+	Entry         string    `json:"entry"` // "type/name"
+	Source        string    `json:"source,omitempty"`
+	Ref           string    `json:"ref,omitempty"`
+	ResolvedRef   string    `json:"resolved_ref,omitempty"`
+	User          string    `json:"user,omitempty"`
+	CursetVersion string    `json:"curset_version,omitempty"`
+}
+
+// CurrentUser returns the name of the user running curset, or "" if unknown.
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// AppendHistory appends events to the history log of cursorDir.
+func AppendHistory(cursorDir string, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	path := filepath.Join(cursorDir, HistoryFileName)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	for _, e := range events {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to marshal history event: %w", err)
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write history: %w", err)
+		}
+	}
+	return f.Sync()
+}
+
+// ReadHistory returns the history log of cursorDir, oldest first.
+// Returns nil if nothing has been recorded yet.
+func ReadHistory(cursorDir string) ([]Event, error) {
+	f, err := os.Open(filepath.Join(cursorDir, HistoryFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse history line %d: %w", line, err)
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return events, nil
+}
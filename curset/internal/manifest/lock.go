@@ -0,0 +1,187 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// LockFileName is the advisory lock file curset holds inside a .cursor/ folder while it
+// loads, modifies and saves the manifest.
+const LockFileName = ".curset.lock"
+
+// takeoverSuffix names the file that serializes taking over a stale lock.
+const takeoverSuffix = ".takeover"
+
+// TakeoverFileName is the file next to the lock that serializes taking over a stale lock.
+const TakeoverFileName = LockFileName + takeoverSuffix
+
+const (
+	lockPoll     = 100 * time.Millisecond
+	staleLockAge = 10 * time.Minute // a lock not renewed for this long was left behind by a crashed run
+)
+
+// Variables so that tests can shorten them.
+var (
+	lockTimeout = 30 * time.Second // how long to wait for another curset run
+	lockRefresh = time.Minute      // how often a held lock's modification time is renewed
+)
+
+// Lock is a held manifest lock.
+type Lock struct {
+	path       string
+	dir        string
+	createdDir bool          // the .cursor/ folder was created to hold the lock
+	stop       chan struct{} // stops renewing the lock
+}
+
+// AcquireLock takes the lock of cursorDir, waiting for other curset runs to release it.
+// A held lock is renewed every lockRefresh, so a long run never looks abandoned. A lock is
+// taken over when the run that holds it is known to have exited (same host, pid gone), or
+// when it has not been renewed for staleLockAge.
+func AcquireLock(cursorDir string) (*Lock, error) {
+	createdDir := false
+	if _, err := os.Stat(cursorDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(cursorDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory %s: %w", cursorDir, err)
+		}
+		createdDir = true
+	}
+
+	path := filepath.Join(cursorDir, LockFileName)
+	deadline := time.Now().Add(lockTimeout)
+	waiting := false
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			host, _ := os.Hostname()
+			fmt.Fprintf(f, "pid %d\nhost %s\n", os.Getpid(), host)
+			f.Close()
+			l := &Lock{path: path, dir: cursorDir, createdDir: createdDir, stop: make(chan struct{})}
+			go l.renew()
+			return l, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock %s: %w", path, err)
+		}
+
+		if info, err := os.Stat(path); err == nil && isStale(path, info) {
+			if takeOver(path, info) {
+				fmt.Fprintf(os.Stderr, "Warning: removed stale lock %s\n", path)
+			}
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another curset run; if none is running, remove %s", cursorDir, path)
+		}
+		if !waiting {
+			fmt.Fprintf(os.Stderr, "Waiting for another curset run to release %s...\n", path)
+			waiting = true
+		}
+		time.Sleep(lockPoll)
+	}
+}
+
+// renew updates the lock's modification time every lockRefresh until Release.
+func (l *Lock) renew() {
+	ticker := time.NewTicker(lockRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			os.Chtimes(l.path, now, now)
+		}
+	}
+}
+
+// isStale reports whether the lock at path was left behind: its process is known to be
+// gone, or it has not been renewed for staleLockAge.
+func isStale(path string, info os.FileInfo) bool {
+	if pid, host := lockOwner(path); pid > 0 {
+		if h, err := os.Hostname(); err == nil && h == host {
+			if alive, known := processAlive(pid); known && !alive {
+				return true
+			}
+		}
+	}
+	return time.Since(info.ModTime()) > staleLockAge
+}
+
+// lockOwner reads the pid and host recorded in the lock at path.
+func lockOwner(path string) (pid int, host string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "pid":
+			fmt.Sscanf(value, "%d", &pid)
+		case "host":
+			host = value
+		}
+	}
+	return pid, host
+}
+
+// processAlive reports whether the process pid exists. known is false where the platform
+// can't tell.
+func processAlive(pid int) (alive, known bool) {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false, true
+	}
+	err = p.Signal(syscall.Signal(0))
+	switch {
+	case err == nil, errors.Is(err, syscall.EPERM):
+		return true, true
+	case errors.Is(err, os.ErrProcessDone), errors.Is(err, syscall.ESRCH):
+		return false, true
+	}
+	return false, false
+}
+
+// takeOver removes the lock at path if it is still the stale lock described by stale.
+// Waiters take over one at a time through a second lock file, and the lock is checked again
+// before it is removed, so a waiter can't remove a lock another one has just created.
+func takeOver(path string, stale os.FileInfo) bool {
+	guard := path + takeoverSuffix
+	f, err := os.OpenFile(guard, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		// Another waiter is taking over; a guard this old was left behind by a crash.
+		if info, err := os.Stat(guard); err == nil && time.Since(info.ModTime()) > lockTimeout {
+			os.Remove(guard)
+		}
+		time.Sleep(lockPoll)
+		return false
+	}
+	f.Close()
+	defer os.Remove(guard)
+
+	info, err := os.Stat(path)
+	if err != nil || !os.SameFile(info, stale) || !info.ModTime().Equal(stale.ModTime()) {
+		return false
+	}
+	return os.Remove(path) == nil
+}
+
+// Release removes the lock, and the .cursor/ folder if it was only created to hold it.
+func (l *Lock) Release() error {
+	close(l.stop)
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock %s: %w", l.path, err)
+	}
+	if l.createdDir {
+		os.Remove(l.dir) // fails, as intended, once anything was installed
+	}
+	return nil
+}
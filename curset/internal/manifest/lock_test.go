@@ -0,0 +1,224 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeLock leaves a lock in dir as if held by pid on host, last renewed at modTime.
+func writeLock(t *testing.T, dir string, pid int, host string, modTime time.Time) string {
+	t.Helper()
+	path := filepath.Join(dir, LockFileName)
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("pid %d\nhost %s\n", pid, host)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// exitedPid returns the pid of a process that has already exited.
+func exitedPid(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("can't start a process: %v", err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestAcquireLock(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".cursor")
+
+	lock, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("AcquireLock() error = %v", err)
+	}
+	if pid, _ := lockOwner(filepath.Join(dir, LockFileName)); pid != os.Getpid() {
+		t.Errorf("lock records pid %d, want %d", pid, os.Getpid())
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("folder created for the lock still exists after Release: %v", err)
+	}
+}
+
+func TestAcquireLockTakesOverStaleLock(t *testing.T) {
+	host, err := os.Hostname()
+	if err != nil {
+		t.Skipf("no hostname: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		pid     int
+		host    string
+		modTime time.Time
+	}{
+		{name: "exited process on this host", pid: exitedPid(t), host: host, modTime: time.Now()},
+		{name: "not renewed on another host", pid: os.Getpid(), host: "elsewhere", modTime: time.Now().Add(-2 * staleLockAge)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeLock(t, dir, tt.pid, tt.host, tt.modTime)
+
+			lock, err := AcquireLock(dir)
+			if err != nil {
+				t.Fatalf("AcquireLock() error = %v", err)
+			}
+			defer lock.Release()
+			if pid, _ := lockOwner(filepath.Join(dir, LockFileName)); pid != os.Getpid() {
+				t.Errorf("lock records pid %d after takeover, want %d", pid, os.Getpid())
+			}
+			if _, err := os.Stat(filepath.Join(dir, LockFileName+takeoverSuffix)); !os.IsNotExist(err) {
+				t.Errorf("takeover guard left behind: %v", err)
+			}
+		})
+	}
+}
+
+func TestAcquireLockWaitsForLiveLock(t *testing.T) {
+	defer func(timeout time.Duration) { lockTimeout = timeout }(lockTimeout)
+	lockTimeout = 300 * time.Millisecond
+
+	tests := []struct {
+		name string
+		host string
+	}{
+		{name: "running process on this host"},
+		{name: "recently renewed on another host", host: "elsewhere"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := tt.host
+			if host == "" {
+				h, err := os.Hostname()
+				if err != nil {
+					t.Skipf("no hostname: %v", err)
+				}
+				host = h
+			}
+			dir := t.TempDir()
+			path := writeLock(t, dir, os.Getpid(), host, time.Now())
+
+			if _, err := AcquireLock(dir); err == nil || !strings.Contains(err.Error(), "locked by another curset run") {
+				t.Fatalf("AcquireLock() error = %v, want the folder to be locked", err)
+			}
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("live lock was removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestLockRenew(t *testing.T) {
+	defer func(refresh time.Duration) { lockRefresh = refresh }(lockRefresh)
+	lockRefresh = 20 * time.Millisecond
+
+	dir := t.TempDir()
+	lock, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("AcquireLock() error = %v", err)
+	}
+	defer lock.Release()
+
+	old := time.Now().Add(-2 * staleLockAge)
+	if err := os.Chtimes(lock.path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		info, err := os.Stat(lock.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isStale(lock.path, info) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("held lock was not renewed, last modified %s", info.ModTime())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTakeOver(t *testing.T) {
+	t.Run("stale lock", func(t *testing.T) {
+		path := writeLock(t, t.TempDir(), 1, "elsewhere", time.Now().Add(-2*staleLockAge))
+		info, _ := os.Stat(path)
+		if !takeOver(path, info) {
+			t.Fatal("takeOver() = false, want the stale lock removed")
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("stale lock still exists: %v", err)
+		}
+	})
+
+	t.Run("lock renewed meanwhile", func(t *testing.T) {
+		path := writeLock(t, t.TempDir(), 1, "elsewhere", time.Now().Add(-2*staleLockAge))
+		stale, _ := os.Stat(path)
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		if takeOver(path, stale) {
+			t.Fatal("takeOver() = true, want a renewed lock kept")
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("renewed lock was removed: %v", err)
+		}
+	})
+
+	t.Run("lock replaced meanwhile", func(t *testing.T) {
+		path := writeLock(t, t.TempDir(), 1, "elsewhere", time.Now().Add(-2*staleLockAge))
+		stale, _ := os.Stat(path)
+		// Another run removed the stale lock and created its own, with the same modification time.
+		other := writeLock(t, t.TempDir(), os.Getpid(), "elsewhere", stale.ModTime())
+		if err := os.Rename(other, path); err != nil {
+			t.Fatal(err)
+		}
+		if takeOver(path, stale) {
+			t.Fatal("takeOver() = true, want a lock created by another run kept")
+		}
+	})
+
+	t.Run("another waiter is taking over", func(t *testing.T) {
+		path := writeLock(t, t.TempDir(), 1, "elsewhere", time.Now().Add(-2*staleLockAge))
+		stale, _ := os.Stat(path)
+		guard := path + takeoverSuffix
+		if err := os.WriteFile(guard, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if takeOver(path, stale) {
+			t.Fatal("takeOver() = true while another waiter holds the guard")
+		}
+		if _, err := os.Stat(guard); err != nil {
+			t.Errorf("fresh guard was removed: %v", err)
+		}
+	})
+
+	t.Run("guard left behind by a crash", func(t *testing.T) {
+		path := writeLock(t, t.TempDir(), 1, "elsewhere", time.Now().Add(-2*staleLockAge))
+		stale, _ := os.Stat(path)
+		guard := path + takeoverSuffix
+		if err := os.WriteFile(guard, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		old := time.Now().Add(-2 * lockTimeout)
+		os.Chtimes(guard, old, old)
+		takeOver(path, stale)
+		if _, err := os.Stat(guard); !os.IsNotExist(err) {
+			t.Errorf("abandoned guard still exists: %v", err)
+		}
+		if !takeOver(path, stale) {
+			t.Fatal("takeOver() = false after the abandoned guard was cleared")
+		}
+	})
+}
@@ -1,40 +1,189 @@
 package manifest
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+)
+
+// FileName is the manifest file name inside a .cursor/ folder.
+const FileName = ".curset.json"
+
+// BackupDir is the folder inside .cursor/ that --backup moves conflicting content to.
+const BackupDir = ".curset-backups"
+
+// StagingPrefix starts the name of the temporary folder inside .cursor/ that an entry is
+// downloaded to before it replaces unmanaged content.
+const StagingPrefix = ".curset-staging-"
+
+// IsStateFile reports whether name is one of the files and folders curset keeps in a
+// .cursor/ folder (manifest, lock, history, backups and staging) rather than installed content.
+func IsStateFile(name string) bool {
+	return name == FileName || name == LockFileName || name == TakeoverFileName ||
+		name == HistoryFileName || name == BackupDir || strings.HasPrefix(name, StagingPrefix)
+}
+
+// OwnerAdded is the owner of entries added directly with 'curset add', and of their dependencies.
+const OwnerAdded = "add"
+
+// collectionOwnerPrefix marks owners that are installed collections.
+const collectionOwnerPrefix = "collection:"
+
+// CollectionOwner returns the owner of the entries an installed collection selected.
+func CollectionOwner(name string) string {
+	return collectionOwnerPrefix + name
+}
+
+// OwnerCollection returns the collection an owner stands for, and false for OwnerAdded.
+func OwnerCollection(owner string) (string, bool) {
+	return strings.CutPrefix(owner, collectionOwnerPrefix)
+}
+
+// DescribeOwner returns a readable form of an owner, e.g. "collection devops" or "direct request".
+func DescribeOwner(owner string) string {
+	if name, ok := OwnerCollection(owner); ok {
+		return "collection " + name
+	}
+	return "direct request"
+}
+
+// Ways to install over content that exists locally but is not managed by curset.
+const (
+	ConflictForce  = "force"  // overwrite it; the original is kept in the snapshot taken before the install
+	ConflictBackup = "backup" // move it to a timestamped folder under BackupDir first
+	ConflictRename = "rename" // leave it alone and install beside it under another name
 )
 
-const manifestPath = ".cursor/.curset.json"
+// Conflict records how an entry was installed over unmanaged local content, so that
+// uninstalling the entry can restore that content.
+type Conflict struct {
+	Mode     string   `json:"mode"`               // ConflictForce, ConflictBackup or ConflictRename
+	Paths    []string `json:"paths"`              // the original content, relative to the .cursor/ folder
+	Backup   string   `json:"backup,omitempty"`   // backup: folder holding Paths, relative to the .cursor/ folder
+	Snapshot string   `json:"snapshot,omitempty"` // force: snapshot holding the overwritten content
+}
+
+// CollectionSource is the repository an installed collection was installed from.
+type CollectionSource struct {
+	Source string `json:"source"`        // GitHub "owner/repo" or absolute path of a local checkout
+	Ref    string `json:"ref,omitempty"` // branch, tag or commit; empty for local checkouts
+}
+
+// String returns the source as "repo@ref", or just the repo if it has no ref.
+func (s CollectionSource) String() string {
+	if s.Ref == "" {
+		return s.Source
+	}
+	return s.Source + "@" + s.Ref
+}
 
 // Entry represents a single installed item tracked by curset.
 type Entry struct {
-	Type  string   `json:"type"`  // object type, e.g. "rules", "commands"
-	Name  string   `json:"name"`  // entry name, e.g. "common", "get-conflict-responsible"
-	IsDir bool     `json:"is_dir"`
-	Files []string `json:"files"` // list of file paths relative to .cursor/
+	Type   string   `json:"type"` // object type, e.g. "rules", "commands"
+	Name   string   `json:"name"` // entry name, e.g. "common", "get-conflict-responsible"
+	IsDir  bool     `json:"is_dir"`
+	Files  []string `json:"files"`            // list of file paths relative to the .cursor/ folder
+	Source string   `json:"source,omitempty"` // repository the entry was fetched from, e.g. "bilgehannal/cursor-config"
+	Ref    string   `json:"ref,omitempty"`    // branch, tag or commit the entry was fetched from
+	Linked bool     `json:"linked,omitempty"` // installed as symlinks into a local source instead of copies
+
+	LocalName string    `json:"local_name,omitempty"` // name in .cursor/ when installed under another name (--rename)
+	Conflict  *Conflict `json:"conflict,omitempty"`   // unmanaged content the entry was installed over
+
+	Checksums map[string]Checksum `json:"checksums,omitempty"` // content of each file in Files, by path
+
+	DependsOn []string `json:"depends_on,omitempty"` // entries ("type/name") this entry requires
+
+	// Provenance of the installed content.
+	SourceURL     string    `json:"source_url,omitempty"`     // web or file:// URL of Source
+	ResolvedRef   string    `json:"resolved_ref,omitempty"`   // commit Ref pointed to at install time
+	InstalledAt   time.Time `json:"installed_at,omitzero"`    // first install
+	UpdatedAt     time.Time `json:"updated_at,omitzero"`      // latest install or update
+	InstalledBy   string    `json:"installed_by,omitempty"`   // user who ran the latest install or update
+	CursetVersion string    `json:"curset_version,omitempty"` // curset version of the latest install or update
+}
+
+// Local returns the name the entry is installed under in its object type folder.
+func (e *Entry) Local() string {
+	if e.LocalName != "" {
+		return e.LocalName
+	}
+	return e.Name
+}
+
+// Checksum identifies the content of an installed file.
+type Checksum struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// Sum returns the checksum of data.
+func Sum(data []byte) Checksum {
+	sum := sha256.Sum256(data)
+	return Checksum{SHA256: hex.EncodeToString(sum[:]), Size: int64(len(data))}
+}
+
+// SumFile returns the checksum of the file at path.
+func SumFile(path string) (Checksum, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Checksum{}, err
+	}
+	return Sum(data), nil
 }
 
 // Manifest tracks all items installed by curset.
 type Manifest struct {
-	Collections []string `json:"collections"` // list of installed collection names
-	Entries     []Entry  `json:"entries"`
+	path string // file the manifest is loaded from and saved to
+
+	SchemaVersion int                          `json:"schema_version"`      // format of the file, see SchemaVersion
+	Collections   []string                     `json:"collections"`         // list of installed collection names
+	Filters       map[string]collection.Filter `json:"filters,omitempty"`   // install filters by collection name
+	Requested     []string                     `json:"requested,omitempty"` // entries ("type/name") added directly, outside any collection
+
+	// CollectionEntries holds the entries ("type/name") each installed collection selected at
+	// install time, dependencies included, so uninstalling works without collection.json.
+	CollectionEntries map[string][]string `json:"collection_entries,omitempty"`
+	// CollectionListed holds the entries each installed collection lists itself, before
+	// dependencies are added: the roots of the dependency chains that pulled in the rest.
+	CollectionListed map[string][]string `json:"collection_listed,omitempty"`
+	// CollectionSources holds the repository each installed collection was installed from, so
+	// that it is checked against its own collection.json later. Collections installed before
+	// sources were recorded have none.
+	CollectionSources map[string]CollectionSource `json:"collection_sources,omitempty"`
+	// EstimatedEntries lists the collections whose CollectionEntries were estimated when
+	// the manifest was upgraded (see migrateV1) rather than recorded at install time.
+	EstimatedEntries []string `json:"estimated_entries,omitempty"`
+	Entries          []Entry  `json:"entries"`
 }
 
-// Load reads the manifest from .cursor/.curset.json.
+// Load reads the manifest from .curset.json inside cursorDir (e.g. ".cursor").
 // Returns an empty manifest if the file does not exist.
-func Load() (*Manifest, error) {
-	data, err := os.ReadFile(manifestPath)
+func Load(cursorDir string) (*Manifest, error) {
+	path := filepath.Join(cursorDir, FileName)
+
+	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &Manifest{}, nil
+			return &Manifest{path: path, SchemaVersion: SchemaVersion}, nil
 		}
 		return nil, fmt.Errorf("failed to read manifest: %w", err)
 	}
 
-	var m Manifest
+	data, err = migrate(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	m := Manifest{path: path}
 	if err := json.Unmarshal(data, &m); err != nil {
 		return nil, fmt.Errorf("failed to parse manifest: %w", err)
 	}
@@ -42,25 +191,64 @@ func Load() (*Manifest, error) {
 	return &m, nil
 }
 
-// Save writes the manifest to .cursor/.curset.json.
+// Save writes the manifest back to the file it was loaded from. The file is replaced
+// atomically, so a crash mid-write leaves the previous version intact.
 func (m *Manifest) Save() error {
-	dir := filepath.Dir(manifestPath)
+	dir := filepath.Dir(m.path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create directory %s: %w", dir, err)
 	}
 
+	m.SchemaVersion = SchemaVersion
 	data, err := json.MarshalIndent(m, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal manifest: %w", err)
 	}
 
-	if err := os.WriteFile(manifestPath, data, 0644); err != nil {
+	if err := writeFileAtomic(m.path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write manifest: %w", err)
 	}
 
 	return nil
 }
 
+// writeFileAtomic writes data to a temporary file next to path, syncs it to disk and
+// renames it over path.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp) // no-op once renamed
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	// Persist the rename itself. Directories can't be synced on every platform, so this is best effort.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
 // IsManaged checks if a given type/name entry is tracked by curset.
 func (m *Manifest) IsManaged(objType, name string) bool {
 	for _, e := range m.Entries {
@@ -88,8 +276,13 @@ func (m *Manifest) AddCollection(name string) {
 	}
 }
 
-// RemoveCollection removes a collection name from the installed list.
+// RemoveCollection removes a collection name, its filter, entry list and source from the installed list.
 func (m *Manifest) RemoveCollection(name string) {
+	delete(m.Filters, name)
+	delete(m.CollectionEntries, name)
+	delete(m.CollectionListed, name)
+	delete(m.CollectionSources, name)
+	m.EstimatedEntries = without(m.EstimatedEntries, name)
 	for i, c := range m.Collections {
 		if c == name {
 			m.Collections = append(m.Collections[:i], m.Collections[i+1:]...)
@@ -98,6 +291,118 @@ func (m *Manifest) RemoveCollection(name string) {
 	}
 }
 
+// Filter returns the filter a collection was installed with.
+func (m *Manifest) Filter(name string) collection.Filter {
+	return m.Filters[name]
+}
+
+// SetFilter records the filter a collection was installed with.
+// An empty filter removes the record.
+func (m *Manifest) SetFilter(name string, f collection.Filter) {
+	if f.IsEmpty() {
+		delete(m.Filters, name)
+		return
+	}
+	if m.Filters == nil {
+		m.Filters = make(map[string]collection.Filter)
+	}
+	m.Filters[name] = f
+}
+
+// SourceOf returns the repository an installed collection was installed from, and false if
+// it was not recorded.
+func (m *Manifest) SourceOf(name string) (CollectionSource, bool) {
+	src, ok := m.CollectionSources[name]
+	return src, ok
+}
+
+// SetSourceOf records the repository an installed collection was installed from.
+func (m *Manifest) SetSourceOf(name string, src CollectionSource) {
+	if m.CollectionSources == nil {
+		m.CollectionSources = make(map[string]CollectionSource)
+	}
+	m.CollectionSources[name] = src
+}
+
+// EntriesOf returns the entries ("type/name") recorded for an installed collection.
+func (m *Manifest) EntriesOf(name string) []string {
+	return m.CollectionEntries[name]
+}
+
+// ListedBy returns the entries ("type/name") an installed collection lists itself, or nil if
+// they were not recorded (manifests written by older curset versions).
+func (m *Manifest) ListedBy(name string) []string {
+	return m.CollectionListed[name]
+}
+
+// SetEntriesOf records the entries ("type/name") an installed collection selected: the
+// ones it lists itself, and all of them including their dependencies.
+func (m *Manifest) SetEntriesOf(name string, listed, refs []string) {
+	if m.CollectionEntries == nil {
+		m.CollectionEntries = make(map[string][]string)
+	}
+	if m.CollectionListed == nil {
+		m.CollectionListed = make(map[string][]string)
+	}
+	m.CollectionEntries[name] = refs
+	m.CollectionListed[name] = listed
+	m.EstimatedEntries = without(m.EstimatedEntries, name)
+}
+
+// without returns list without the value s.
+func without(list []string, s string) []string {
+	var out []string
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Owners returns the owners of every entry ("type/name") needed by the installed
+// collections and directly requested entries: each collection owns the entries recorded
+// for it, and OwnerAdded owns the requested entries together with their dependencies.
+func (m *Manifest) Owners() map[string][]string {
+	owners := make(map[string][]string)
+	for _, name := range m.Collections {
+		for _, ref := range m.EntriesOf(name) {
+			owners[ref] = append(owners[ref], CollectionOwner(name))
+		}
+	}
+	for _, ref := range m.WithDependencies(m.Requested) {
+		owners[ref] = append(owners[ref], OwnerAdded)
+	}
+	return owners
+}
+
+// IsRequested checks if an entry ("type/name") was added directly.
+func (m *Manifest) IsRequested(ref string) bool {
+	for _, r := range m.Requested {
+		if r == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// AddRequested marks an entry ("type/name") as directly requested if not already marked.
+func (m *Manifest) AddRequested(ref string) {
+	if !m.IsRequested(ref) {
+		m.Requested = append(m.Requested, ref)
+	}
+}
+
+// RemoveRequested removes an entry ("type/name") from the directly requested list.
+func (m *Manifest) RemoveRequested(ref string) {
+	for i, r := range m.Requested {
+		if r == ref {
+			m.Requested = append(m.Requested[:i], m.Requested[i+1:]...)
+			return
+		}
+	}
+}
+
 // AddOrUpdate adds a new entry or updates an existing one in the manifest.
 func (m *Manifest) AddOrUpdate(entry Entry) {
 	for i, e := range m.Entries {
@@ -128,3 +433,105 @@ func (m *Manifest) GetEntry(objType, name string) *Entry {
 	}
 	return nil
 }
+
+// Paths returns the paths of the installed entries relative to the .cursor/ folder: the
+// folder of each directory entry and the files of each file entry, sorted.
+func (m *Manifest) Paths() []string {
+	var paths []string
+	for _, e := range m.Entries {
+		if e.IsDir {
+			paths = append(paths, filepath.Join(e.Type, e.Local()))
+			continue
+		}
+		paths = append(paths, e.Files...)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ForcedSnapshots returns the entries ("type/name") installed with --force over content that
+// is kept in a snapshot, by snapshot ID. Those snapshots are needed to restore the content.
+func (m *Manifest) ForcedSnapshots() map[string][]string {
+	snaps := make(map[string][]string)
+	for _, e := range m.Entries {
+		if e.Conflict != nil && e.Conflict.Mode == ConflictForce && e.Conflict.Snapshot != "" {
+			snaps[e.Conflict.Snapshot] = append(snaps[e.Conflict.Snapshot], e.Type+"/"+e.Name)
+		}
+	}
+	return snaps
+}
+
+// WithDependencies returns refs ("type/name") together with the managed entries they
+// depend on, directly or transitively, as recorded in DependsOn.
+func (m *Manifest) WithDependencies(refs []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	queue := append([]string(nil), refs...)
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		out = append(out, ref)
+
+		for _, e := range m.Entries {
+			if e.Type+"/"+e.Name == ref {
+				queue = append(queue, e.DependsOn...)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// DependencyPath returns the shortest chain of entries ("type/name") that starts at one of
+// roots and follows DependsOn to ref, e.g. [rules/go rules/common]. It returns nil if
+// ref can't be reached from roots.
+func (m *Manifest) DependencyPath(roots []string, ref string) []string {
+	prev := make(map[string]string)
+	seen := make(map[string]bool)
+	queue := append([]string(nil), roots...)
+	for _, r := range roots {
+		seen[r] = true
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == ref {
+			path := []string{cur}
+			for p, ok := prev[cur]; ok; p, ok = prev[p] {
+				path = append([]string{p}, path...)
+			}
+			return path
+		}
+
+		objType, name, _ := collection.SplitRef(cur)
+		if e := m.GetEntry(objType, name); e != nil {
+			for _, dep := range e.DependsOn {
+				if !seen[dep] {
+					seen[dep] = true
+					prev[dep] = cur
+					queue = append(queue, dep)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Dependents returns the "type/name" references of entries that depend on the given entry.
+func (m *Manifest) Dependents(objType, name string) []string {
+	ref := objType + "/" + name
+	var out []string
+	for _, e := range m.Entries {
+		for _, dep := range e.DependsOn {
+			if dep == ref {
+				out = append(out, e.Type+"/"+e.Name)
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOwners(t *testing.T) {
+	entries := []Entry{
+		{Type: "rules", Name: "go", DependsOn: []string{"rules/common"}},
+		{Type: "rules", Name: "common"},
+		{Type: "rules", Name: "python"},
+		{Type: "commands", Name: "review", DependsOn: []string{"rules/go"}},
+		{Type: "rules", Name: "orphan"},
+	}
+
+	tests := []struct {
+		name        string
+		collections []string
+		selected    map[string][]string
+		requested   []string
+		want        map[string]string // ref -> owners, comma-separated
+	}{
+		{
+			name: "nothing installed",
+			want: map[string]string{},
+		},
+		{
+			name:        "collections own their recorded entries",
+			collections: []string{"go", "python"},
+			selected:    map[string][]string{"go": {"rules/go", "rules/common"}, "python": {"rules/python", "rules/common"}},
+			want: map[string]string{
+				"rules/go":     "collection:go",
+				"rules/common": "collection:go,collection:python",
+				"rules/python": "collection:python",
+			},
+		},
+		{
+			name:      "direct requests own their dependencies",
+			requested: []string{"commands/review"},
+			want: map[string]string{
+				"commands/review": "add",
+				"rules/go":        "add",
+				"rules/common":    "add",
+			},
+		},
+		{
+			name:        "collection and direct request",
+			collections: []string{"python"},
+			selected:    map[string][]string{"python": {"rules/python", "rules/common"}},
+			requested:   []string{"rules/go"},
+			want: map[string]string{
+				"rules/python": "collection:python",
+				"rules/common": "collection:python,add",
+				"rules/go":     "add",
+			},
+		},
+		{
+			name:        "entries of uninstalled collections are not owned",
+			collections: []string{"python"},
+			selected:    map[string][]string{"go": {"rules/go"}, "python": {"rules/python"}},
+			want:        map[string]string{"rules/python": "collection:python"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manifest{Collections: tt.collections, CollectionEntries: tt.selected, Requested: tt.requested, Entries: entries}
+			got := m.Owners()
+			if len(got) != len(tt.want) {
+				t.Fatalf("Owners() = %v, want %v", got, tt.want)
+			}
+			for ref, want := range tt.want {
+				if owners := strings.Join(got[ref], ","); owners != want {
+					t.Errorf("owners of %s = %s, want %s", ref, owners, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDescribeOwner(t *testing.T) {
+	tests := map[string]string{
+		CollectionOwner("devops"): "collection devops",
+		OwnerAdded:                "direct request",
+	}
+	for owner, want := range tests {
+		if got := DescribeOwner(owner); got != want {
+			t.Errorf("DescribeOwner(%q) = %q, want %q", owner, got, want)
+		}
+		if name, ok := OwnerCollection(owner); ok != (owner != OwnerAdded) || (ok && name != "devops") {
+			t.Errorf("OwnerCollection(%q) = %q, %v", owner, name, ok)
+		}
+	}
+}
+
+func TestSaveDoesNotStoreOwners(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.AddCollection("go")
+	m.SetEntriesOf("go", []string{"rules/go"}, []string{"rules/go"})
+	m.AddOrUpdate(Entry{Type: "rules", Name: "go"})
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"owners"`) {
+		t.Errorf("saved manifest stores owners, which are computed:\n%s", data)
+	}
+}
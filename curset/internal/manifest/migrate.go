@@ -0,0 +1,116 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// SchemaVersion is the manifest format written by this version of curset.
+// Bump it together with a new entry in migrations whenever the format changes.
+const SchemaVersion = 3
+
+// migrations upgrade a raw manifest one version at a time: migrations[i] turns a
+// version i manifest into a version i+1 one. Manifests without schema_version are version 0.
+var migrations = []func(raw map[string]json.RawMessage) error{
+	migrateV0,
+	migrateV1,
+	migrateV2,
+}
+
+// migrate upgrades the manifest data read from path to SchemaVersion. It refuses
+// manifests written by a newer curset, whose format it cannot know.
+func migrate(path string, data []byte) ([]byte, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	version := 0
+	if v, ok := raw["schema_version"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: schema_version: %w", err)
+		}
+	}
+
+	if version < 0 {
+		return nil, fmt.Errorf("%s has invalid schema version %d", path, version)
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("%s has schema version %d, but this curset only understands up to version %d; upgrade curset to manage this folder", path, version, SchemaVersion)
+	}
+	if version == SchemaVersion {
+		return data, nil
+	}
+
+	for v := version; v < SchemaVersion; v++ {
+		if err := migrations[v](raw); err != nil {
+			return nil, fmt.Errorf("failed to migrate %s from schema version %d: %w", path, v, err)
+		}
+	}
+	raw["schema_version"], _ = json.Marshal(SchemaVersion)
+
+	return json.Marshal(raw)
+}
+
+// migrateV0 upgrades manifests written before schema_version existed. Version 1 only
+// added the field itself, so there is nothing else to change.
+func migrateV0(raw map[string]json.RawMessage) error {
+	return nil
+}
+
+// migrateV1 adds collection_entries. Version 1 did not record which collection installed
+// which entry, so every collection is assumed to own all entries that were not added
+// directly. That never removes an entry another collection still uses. The collections
+// are listed in estimated_entries, so that the next command with collection.json at hand
+// records their exact entries.
+func migrateV1(raw map[string]json.RawMessage) error {
+	var collections, requested []string
+	var entries []struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}
+	for key, v := range map[string]any{"collections": &collections, "requested": &requested, "entries": &entries} {
+		if data, ok := raw[key]; ok {
+			if err := json.Unmarshal(data, v); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+	if len(collections) == 0 {
+		return nil
+	}
+
+	isRequested := make(map[string]bool, len(requested))
+	for _, ref := range requested {
+		isRequested[ref] = true
+	}
+	refs := []string{}
+	for _, e := range entries {
+		if ref := e.Type + "/" + e.Name; !isRequested[ref] {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Strings(refs)
+
+	owned := make(map[string][]string, len(collections))
+	for _, name := range collections {
+		owned[name] = refs
+	}
+	data, err := json.Marshal(owned)
+	if err != nil {
+		return err
+	}
+	raw["collection_entries"] = data
+	if raw["estimated_entries"], err = json.Marshal(collections); err != nil {
+		return err
+	}
+	return nil
+}
+
+// migrateV2 upgrades manifests written before collection_sources existed. The sources
+// collections were installed from are unknown, so none is recorded: prune and outdated
+// then check those collections against the current source, without forgetting them.
+func migrateV2(raw map[string]json.RawMessage) error {
+	return nil
+}
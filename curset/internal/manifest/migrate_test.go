@@ -0,0 +1,159 @@
+package manifest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "version 0 (no schema_version)", input: `{"collections":[],"entries":[]}`},
+		{name: "version 0 with entries", input: `{"collections":[],"entries":[{"type":"rules","name":"go","is_dir":true,"files":["rules/go/a.mdc"]}]}`},
+		{name: "version 1", input: `{"schema_version":1,"collections":[],"entries":[]}`},
+		{name: "version 2", input: `{"schema_version":2,"collections":[],"entries":[]}`},
+		{name: "current version", input: `{"schema_version":3,"collections":[],"entries":[]}`},
+		{name: "negative version", input: `{"schema_version":-1,"entries":[]}`, wantErr: "invalid schema version -1"},
+		{name: "newer version", input: `{"schema_version":99,"entries":[]}`, wantErr: "upgrade curset"},
+		{name: "version is not a number", input: `{"schema_version":"2","entries":[]}`, wantErr: "schema_version"},
+		{name: "not JSON", input: `{`, wantErr: "failed to parse manifest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := migrate(FileName, []byte(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("migrate() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("migrate() error = %v", err)
+			}
+
+			var m Manifest
+			if err := json.Unmarshal(data, &m); err != nil {
+				t.Fatalf("migrated manifest does not parse: %v", err)
+			}
+			if m.SchemaVersion != SchemaVersion {
+				t.Errorf("schema version = %d, want %d", m.SchemaVersion, SchemaVersion)
+			}
+		})
+	}
+}
+
+func TestMigrationsCoverEveryVersion(t *testing.T) {
+	if len(migrations) != SchemaVersion {
+		t.Fatalf("%d migrations for schema version %d; add one with every version bump", len(migrations), SchemaVersion)
+	}
+}
+
+func TestLoadMigratesAndSaveWritesCurrentVersion(t *testing.T) {
+	dir := t.TempDir()
+	v0 := `{"collections":["go"],"entries":[{"type":"rules","name":"go","is_dir":true,"files":["rules/go/a.mdc"]}]}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(v0), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if m.SchemaVersion != SchemaVersion || !m.IsManaged("rules", "go") {
+		t.Fatalf("Load() = %+v", m)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.SchemaVersion != SchemaVersion {
+		t.Errorf("saved schema version = %d, want %d", saved.SchemaVersion, SchemaVersion)
+	}
+}
+
+func TestMigrateV1(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantEntries   map[string][]string
+		wantEstimated []string
+	}{
+		{
+			name:  "no collections",
+			input: `{"schema_version":1,"requested":["rules/go"],"entries":[{"type":"rules","name":"go"}]}`,
+		},
+		{
+			name: "collections own every entry not added directly",
+			input: `{"schema_version":1,"collections":["go","python"],"requested":["commands/review"],"entries":[
+				{"type":"rules","name":"go"},{"type":"rules","name":"common"},{"type":"commands","name":"review"}]}`,
+			wantEntries: map[string][]string{
+				"go":     {"rules/common", "rules/go"},
+				"python": {"rules/common", "rules/go"},
+			},
+			wantEstimated: []string{"go", "python"},
+		},
+		{
+			name:          "collection without entries",
+			input:         `{"schema_version":1,"collections":["go"],"entries":[]}`,
+			wantEntries:   map[string][]string{"go": {}},
+			wantEstimated: []string{"go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := migrate(FileName, []byte(tt.input))
+			if err != nil {
+				t.Fatalf("migrate() error = %v", err)
+			}
+			var m Manifest
+			if err := json.Unmarshal(data, &m); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(m.CollectionEntries) != len(tt.wantEntries) {
+				t.Fatalf("collection entries = %v, want %v", m.CollectionEntries, tt.wantEntries)
+			}
+			for name, want := range tt.wantEntries {
+				if got := m.EntriesOf(name); strings.Join(got, ",") != strings.Join(want, ",") {
+					t.Errorf("entries of %s = %v, want %v", name, got, want)
+				}
+			}
+			if strings.Join(m.EstimatedEntries, ",") != strings.Join(tt.wantEstimated, ",") {
+				t.Errorf("estimated entries = %v, want %v", m.EstimatedEntries, tt.wantEstimated)
+			}
+		})
+	}
+}
+
+func TestRecordingEntriesClearsEstimate(t *testing.T) {
+	m := &Manifest{
+		Collections:       []string{"go", "python"},
+		CollectionEntries: map[string][]string{"go": {"rules/go", "rules/python"}, "python": {"rules/go", "rules/python"}},
+		EstimatedEntries:  []string{"go", "python"},
+	}
+
+	m.SetEntriesOf("go", []string{"rules/go"}, []string{"rules/go"})
+	if strings.Join(m.EstimatedEntries, ",") != "python" {
+		t.Fatalf("after SetEntriesOf, estimated entries = %v, want [python]", m.EstimatedEntries)
+	}
+	m.RemoveCollection("python")
+	if len(m.EstimatedEntries) != 0 {
+		t.Fatalf("after RemoveCollection, estimated entries = %v, want none", m.EstimatedEntries)
+	}
+}
@@ -0,0 +1,101 @@
+// Package mdc reads Cursor rule files: markdown with an optional frontmatter block
+// (description, globs, alwaysApply) between "---" lines.
+package mdc
+
+import (
+	"strings"
+)
+
+// Frontmatter holds the rule settings Cursor reads from a rule file.
+type Frontmatter struct {
+	Description string
+	Globs       []string
+	AlwaysApply bool
+	Present     bool // the file has a frontmatter block
+}
+
+// Parse splits a rule file into its frontmatter and markdown body.
+//
+// Cursor's frontmatter is not strict YAML (globs such as "*.go" are left unquoted), so it
+// is read line by line as "key: value" pairs. globs may be a comma-separated string or a
+// ["a", "b"] list. Unknown keys are ignored.
+//
+// Rule files come from remote collections, so control characters other than newlines and
+// tabs are dropped: printed as they are, they could drive the terminal.
+func Parse(data []byte) (Frontmatter, string) {
+	text := Sanitize(strings.ReplaceAll(string(data), "\r\n", "\n"))
+
+	rest, ok := strings.CutPrefix(text, "---\n")
+	if !ok {
+		return Frontmatter{}, text
+	}
+	block, body, ok := cutFence(rest)
+	if !ok {
+		return Frontmatter{}, text
+	}
+	body = strings.TrimPrefix(body, "\n")
+
+	fm := Frontmatter{Present: true}
+	for _, line := range strings.Split(block, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "description":
+			fm.Description = unquote(value)
+		case "globs":
+			fm.Globs = splitGlobs(value)
+		case "alwaysApply":
+			fm.AlwaysApply = value == "true"
+		}
+	}
+	return fm, body
+}
+
+// cutFence splits text at its first line that is exactly "---", the end of a frontmatter
+// block, and returns the text before and after that line.
+func cutFence(text string) (block, body string, found bool) {
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if strings.TrimSuffix(line, "\n") == "---" {
+			return strings.Join(lines[:i], ""), strings.Join(lines[i+1:], ""), true
+		}
+	}
+	return "", "", false
+}
+
+// Sanitize drops the C0 and C1 control characters of s, except newlines and tabs, so that
+// text from a remote collection can't inject terminal escape sequences.
+func Sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if r < 0x20 || (r >= 0x7f && r <= 0x9f) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// splitGlobs reads a globs value, either "*.go, *.mod" or ["*.go", "*.mod"].
+func splitGlobs(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	var globs []string
+	for _, g := range strings.Split(value, ",") {
+		if g = unquote(strings.TrimSpace(g)); g != "" {
+			globs = append(globs, g)
+		}
+	}
+	return globs
+}
+
+// unquote strips matching single or double quotes around s.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,107 @@
+package mdc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantFM   Frontmatter
+		wantBody string
+	}{
+		{
+			name:     "no frontmatter",
+			data:     "# Go\n\nUse gofmt.\n",
+			wantBody: "# Go\n\nUse gofmt.\n",
+		},
+		{
+			name:     "frontmatter",
+			data:     "---\ndescription: \"Go style\"\nglobs: *.go, *.mod\nalwaysApply: true\n---\n\n# Go\n",
+			wantFM:   Frontmatter{Description: "Go style", Globs: []string{"*.go", "*.mod"}, AlwaysApply: true, Present: true},
+			wantBody: "# Go\n",
+		},
+		{
+			name:     "globs as a list",
+			data:     "---\nglobs: [\"*.go\", '*.mod']\n---\n# Go\n",
+			wantFM:   Frontmatter{Globs: []string{"*.go", "*.mod"}, Present: true},
+			wantBody: "# Go\n",
+		},
+		{
+			name:     "empty frontmatter",
+			data:     "---\n---\n# Go\n",
+			wantFM:   Frontmatter{Present: true},
+			wantBody: "# Go\n",
+		},
+		{
+			name:     "CRLF line endings",
+			data:     "---\r\ndescription: Go\r\nalwaysApply: false\r\n---\r\n\r\n# Go\r\n",
+			wantFM:   Frontmatter{Description: "Go", Present: true},
+			wantBody: "# Go\n",
+		},
+		{
+			name:     "closing fence at the end of the file",
+			data:     "---\ndescription: Go\n---",
+			wantFM:   Frontmatter{Description: "Go", Present: true},
+			wantBody: "",
+		},
+		{
+			name:     "missing closing fence",
+			data:     "---\ndescription: Go\n# Go\n",
+			wantBody: "---\ndescription: Go\n# Go\n",
+		},
+		{
+			name:     "longer line is not a fence",
+			data:     "---\ndescription: Go\n----\n# Go\n",
+			wantBody: "---\ndescription: Go\n----\n# Go\n",
+		},
+		{
+			name:     "control characters",
+			data:     "---\ndescription: Go\x1b]0;owned\x07\n---\n# Go\x1b[2J\tstyle\u009b31m\n",
+			wantFM:   Frontmatter{Description: "Go]0;owned", Present: true},
+			wantBody: "# Go[2J\tstyle31m\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, body := Parse([]byte(tt.data))
+			if fm.Description != tt.wantFM.Description || fm.AlwaysApply != tt.wantFM.AlwaysApply ||
+				fm.Present != tt.wantFM.Present || strings.Join(fm.Globs, ",") != strings.Join(tt.wantFM.Globs, ",") {
+				t.Errorf("Parse() frontmatter = %+v, want %+v", fm, tt.wantFM)
+			}
+			if body != tt.wantBody {
+				t.Errorf("Parse() body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "plain text", want: "plain text"},
+		{in: "lines\n\tand tabs", want: "lines\n\tand tabs"},
+		{in: "r\u00e8gles \u2014 \u65e5\u672c", want: "r\u00e8gles \u2014 \u65e5\u672c"},
+		{in: "\x1b[2Jclear", want: "[2Jclear"},
+		{in: "\x1b]8;;http://x\x07link", want: "]8;;http://xlink"},
+		{in: "c1\u009b31m and \u0085 next line", want: "c131m and  next line"},
+		{in: "del\x7f and nul\x00", want: "del and nul"},
+	}
+	for _, tt := range tests {
+		if got := Sanitize(tt.in); got != tt.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderDropsControlCharacters(t *testing.T) {
+	got := Render("# Title\x1b]8;;http://x\x07\nplain \u009b31mtext\n")
+	for _, c := range []string{"\x1b]", "\x07", "\u009b"} {
+		if strings.Contains(got, c) {
+			t.Errorf("Render() = %q, want it without %q", got, c)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package mdc
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	headingStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	codeStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	boldStyle    = lipgloss.NewStyle().Bold(true)
+	ruleStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	inlineCode = regexp.MustCompile("`[^`]+`")
+	bold       = regexp.MustCompile(`\*\*[^*]+\*\*`)
+	bullet     = regexp.MustCompile(`^(\s*)[-*+] `)
+)
+
+// Render formats a markdown body for the terminal: headings are highlighted, list
+// markers become bullets, code blocks and inline code are coloured and bold text is
+// emphasised. Anything else is printed as written, without control characters (see Sanitize).
+func Render(body string) string {
+	var b strings.Builder
+	inCode := false
+	for _, line := range strings.Split(Sanitize(body), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			inCode = !inCode
+			b.WriteString(ruleStyle.Render(line))
+		case inCode:
+			b.WriteString(codeStyle.Render("  " + line))
+		case strings.HasPrefix(trimmed, "#"):
+			b.WriteString(headingStyle.Render(strings.TrimSpace(strings.TrimLeft(trimmed, "#"))))
+		case trimmed == "---" || trimmed == "***":
+			b.WriteString(ruleStyle.Render(strings.Repeat("─", 40)))
+		default:
+			line = bullet.ReplaceAllString(line, "$1• ")
+			line = inlineCode.ReplaceAllStringFunc(line, func(s string) string {
+				return codeStyle.Render(strings.Trim(s, "`"))
+			})
+			line = bold.ReplaceAllStringFunc(line, func(s string) string {
+				return boldStyle.Render(strings.Trim(s, "*"))
+			})
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigPath is the project configuration file, relative to the project directory.
+const ConfigPath = ".curset.yaml"
+
+// Config is the desired state of a project's .cursor/ folder, declared in .curset.yaml.
+type Config struct {
+	// Source is a GitHub repository "owner/repo" or a local checkout; empty means the default
+	// repository. A relative local path ("./", "../") is resolved against the directory holding
+	// .curset.yaml, so the configuration works from any working directory.
+	Source      string   `yaml:"source,omitempty"`
+	Ref         string   `yaml:"ref,omitempty"`         // branch, tag or commit; empty means the default ref
+	Collections []string `yaml:"collections,omitempty"` // collections to install
+	Entries     []string `yaml:"entries,omitempty"`     // extra entries ("type/name") to install
+	Exclude     []string `yaml:"exclude,omitempty"`     // object types or entries to leave out, glob patterns allowed
+}
+
+// Load reads and validates the project configuration from .curset.yaml in dir.
+func Load(dir string) (*Config, error) {
+	path := filepath.Join(dir, ConfigPath)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s not found", path)
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for _, ref := range cfg.Entries {
+		if _, _, err := collection.SplitRef(ref); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := (collection.Filter{Exclude: cfg.Exclude}).Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if source.IsLocal(cfg.Source) && strings.HasPrefix(cfg.Source, ".") {
+		abs, err := filepath.Abs(filepath.Join(dir, cfg.Source))
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to resolve source %s: %w", path, cfg.Source, err)
+		}
+		cfg.Source = abs
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,223 @@
+// Package search indexes the content of the rules and commands of a source and finds
+// the files matching a query.
+package search
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/source"
+)
+
+// Document is one indexed file of an entry.
+type Document struct {
+	Entry       string   `json:"entry"`       // "type/name"
+	Path        string   `json:"path"`        // file path relative to the .cursor/ folder
+	Source      string   `json:"source"`      // repository the entry is fetched from
+	Collections []string `json:"collections"` // collections that include the entry, as a dependency or not
+	Content     string   `json:"content"`
+}
+
+// indexVersion is the format of cached indexes; caches of another version are rebuilt.
+const indexVersion = 2
+
+// objectTypes are the folders of a source whose entries are indexed even if no collection
+// includes them.
+var objectTypes = []string{"rules", "commands"}
+
+// Index holds the content of every entry of a source.
+type Index struct {
+	Version   int            `json:"version"`           // format of the index, see indexVersion
+	Source    string         `json:"source"`            // repository of collection.json
+	Ref       string         `json:"ref,omitempty"`     // branch, tag or commit of Source
+	Commit    string         `json:"commit,omitempty"`  // commit Ref pointed to when the index was built
+	Built     time.Time      `json:"built"`             // when the index was built
+	Sources   []SourceCommit `json:"sources,omitempty"` // sources entries override the default with
+	Skipped   []string       `json:"skipped,omitempty"` // entries that could not be indexed, with the reason
+	Documents []Document     `json:"documents"`
+}
+
+// SourceCommit records the commit a source pointed to when an index was built.
+type SourceCommit struct {
+	Repo   string `json:"repo"`
+	Ref    string `json:"ref,omitempty"`
+	Commit string `json:"commit,omitempty"` // empty for local checkouts, which have no fixed commit
+}
+
+// Build downloads and indexes the files of every entry of src: those in its rules and
+// commands folders and those included by the collections in cf, dependencies included.
+// Entries overriding their source are read from that source. Entries that can't be listed
+// are recorded in Skipped; binary files are left out.
+func Build(src source.Source, cf *collection.CollectionFile) (*Index, error) {
+	commit, _ := src.ResolveRef()
+	idx := &Index{Version: indexVersion, Source: src.Repo(), Ref: src.Ref(), Commit: commit, Built: time.Now().UTC()}
+
+	owners := make(map[string][]string) // "type/name" -> collections including it
+	for _, name := range cf.SortedNames() {
+		col, err := cf.Dependencies.Expand(cf.Collections[name])
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range col.Refs() {
+			owners[ref] = append(owners[ref], name)
+		}
+	}
+	for _, objType := range objectTypes {
+		result, err := src.ListContents(objType)
+		if err != nil {
+			idx.Skipped = append(idx.Skipped, fmt.Sprintf("%s: %v", objType, err))
+			continue
+		}
+		for _, c := range result.Entries {
+			name := c.Name
+			if c.Type != "dir" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			ref := collection.Ref(objType, name)
+			if _, ok := owners[ref]; !ok {
+				owners[ref] = nil
+			}
+		}
+	}
+	refs := make([]string, 0, len(owners))
+	for ref := range owners {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+
+	for _, ref := range refs {
+		objType, name, _ := collection.SplitRef(ref)
+		entrySrc, err := source.ForEntry(src, cf.Lookup(objType, name))
+		if err != nil {
+			idx.Skipped = append(idx.Skipped, fmt.Sprintf("%s: %v", ref, err))
+			continue
+		}
+		if entrySrc != src {
+			idx.addSource(entrySrc)
+		}
+
+		files, _, err := source.RemoteFiles(entrySrc, objType, name)
+		if err != nil {
+			idx.Skipped = append(idx.Skipped, fmt.Sprintf("%s: %v", ref, err))
+			continue
+		}
+		paths := make([]string, 0, len(files))
+		for p := range files {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+
+		for _, p := range paths {
+			data, err := entrySrc.DownloadFile(filepath.ToSlash(p))
+			if err != nil {
+				idx.Skipped = append(idx.Skipped, fmt.Sprintf("%s: %v", p, err))
+				continue
+			}
+			if bytes.IndexByte(data, 0) >= 0 {
+				continue
+			}
+			idx.Documents = append(idx.Documents, Document{
+				Entry:       ref,
+				Path:        filepath.ToSlash(p),
+				Source:      entrySrc.Repo(),
+				Collections: owners[ref],
+				Content:     string(data),
+			})
+		}
+	}
+
+	return idx, nil
+}
+
+// addSource records the commit an entry source other than the default points to.
+func (idx *Index) addSource(src source.Source) {
+	for _, s := range idx.Sources {
+		if s.Repo == src.Repo() && s.Ref == src.Ref() {
+			return
+		}
+	}
+	sc := SourceCommit{Repo: src.Repo(), Ref: src.Ref()}
+	if !source.IsLocal(src.Repo()) {
+		sc.Commit, _ = src.ResolveRef()
+	}
+	idx.Sources = append(idx.Sources, sc)
+}
+
+// Changed reports whether src or any entry source the index was read from now points to
+// another commit than when the index was built. Local checkouts, which can change without
+// a commit, always count as changed.
+func (idx *Index) Changed(src source.Source) (bool, error) {
+	commit, err := src.ResolveRef()
+	if err != nil {
+		return false, err
+	}
+	if commit != idx.Commit {
+		return true, nil
+	}
+	for _, s := range idx.Sources {
+		if s.Commit == "" || source.IsLocal(s.Repo) {
+			return true, nil
+		}
+		commit, err := source.Open(s.Repo, s.Ref).ResolveRef()
+		if err != nil {
+			return false, fmt.Errorf("%s@%s: %w", s.Repo, s.Ref, err)
+		}
+		if commit != s.Commit {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// cachePath returns the file the index of repo at ref is cached in.
+func cachePath(cacheDir, repo, ref string) string {
+	sum := sha256.Sum256([]byte(repo + "@" + ref))
+	return filepath.Join(cacheDir, "search", hex.EncodeToString(sum[:])[:16]+".json")
+}
+
+// LoadCache returns the cached index of src, or nil if there is none or it has an older format.
+func LoadCache(cacheDir string, src source.Source) (*Index, error) {
+	path := cachePath(cacheDir, src.Repo(), src.Ref())
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read search index: %w", err)
+	}
+
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("failed to parse search index %s: %w", path, err)
+	}
+	if idx.Version != indexVersion {
+		return nil, nil
+	}
+	return &idx, nil
+}
+
+// Save writes the index to the cache in cacheDir.
+func (idx *Index) Save(cacheDir string) error {
+	path := cachePath(cacheDir, idx.Source, idx.Ref)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+	}
+
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal search index: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	return nil
+}
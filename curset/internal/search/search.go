@@ -0,0 +1,166 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Score weights of the places a query term can appear in.
+const (
+	pathWeight    = 10 // in the entry name or file path
+	headingWeight = 5  // in a markdown heading
+	phraseWeight  = 20 // the whole query, in order, anywhere in the file
+	maxTermHits   = 20 // occurrences of a term in the content counted at most
+
+	maxLines     = 3   // matching lines returned per file
+	snippetWidth = 120 // runes of a matching line returned around the first match
+)
+
+// Line is a line of a document that matches the query.
+type Line struct {
+	Number int    // 1-based line number
+	Text   string // the line, shortened to the part around the match
+}
+
+// Match is a document that contains every term of the query.
+type Match struct {
+	*Document
+	Score int
+	Lines []Line
+}
+
+// Terms splits a query into the lowercase terms it is matched by.
+func Terms(query string) []string {
+	return strings.Fields(strings.ToLower(query))
+}
+
+// Search returns the documents containing every term of query (case-insensitive), best
+// first. Only entries of the given object types are searched, or all if types is empty.
+// Terms in the entry name or path and in headings rank higher, as does the whole query
+// appearing as a phrase.
+func (idx *Index) Search(query string, types []string) []Match {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var matches []Match
+	for i := range idx.Documents {
+		doc := &idx.Documents[i]
+		if len(types) > 0 && !hasType(types, doc.Entry) {
+			continue
+		}
+
+		content := strings.ToLower(doc.Content)
+		path := strings.ToLower(doc.Path)
+		score := 0
+		found := true
+		for _, term := range terms {
+			hits := strings.Count(content, term)
+			inPath := strings.Contains(path, term)
+			if hits == 0 && !inPath {
+				found = false
+				break
+			}
+			score += min(hits, maxTermHits)
+			if inPath {
+				score += pathWeight
+			}
+		}
+		if !found {
+			continue
+		}
+		if len(terms) > 1 && strings.Contains(content, strings.Join(terms, " ")) {
+			score += phraseWeight
+		}
+
+		lines := matchingLines(doc.Content, terms)
+		for _, l := range lines {
+			if strings.HasPrefix(strings.TrimSpace(l.Text), "#") {
+				score += headingWeight
+			}
+		}
+		matches = append(matches, Match{Document: doc, Score: score, Lines: lines})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Path < matches[j].Path
+	})
+	return matches
+}
+
+// hasType reports whether the entry ("type/name") is of one of types.
+func hasType(types []string, entry string) bool {
+	objType, _, _ := strings.Cut(entry, "/")
+	for _, t := range types {
+		if t == objType {
+			return true
+		}
+	}
+	return false
+}
+
+// matchingLines returns up to maxLines lines of content containing a term, preferring
+// lines that contain more of the terms, in file order.
+func matchingLines(content string, terms []string) []Line {
+	type scored struct {
+		Line
+		terms int
+	}
+	var candidates []scored
+	for i, text := range strings.Split(content, "\n") {
+		lower := strings.ToLower(text)
+		n := 0
+		first := -1
+		for _, term := range terms {
+			if at := strings.Index(lower, term); at >= 0 {
+				n++
+				// Lowercasing keeps the runes but not always their byte length, e.g. "İ".
+				if at = utf8.RuneCountInString(lower[:at]); first < 0 || at < first {
+					first = at
+				}
+			}
+		}
+		if n > 0 {
+			candidates = append(candidates, scored{Line{Number: i + 1, Text: snippet(text, first)}, n})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].terms > candidates[j].terms })
+	if len(candidates) > maxLines {
+		candidates = candidates[:maxLines]
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Number < candidates[j].Number })
+
+	lines := make([]Line, len(candidates))
+	for i, c := range candidates {
+		lines[i] = c.Line
+	}
+	return lines
+}
+
+// snippet trims a line to snippetWidth runes around the rune offset at, marking cuts with "…".
+func snippet(text string, at int) string {
+	trimmed := strings.TrimLeft(text, " \t")
+	at = max(at-(len(text)-len(trimmed)), 0)
+	text = strings.TrimRight(trimmed, " \t\r")
+	r := []rune(text)
+	if len(r) <= snippetWidth {
+		return text
+	}
+
+	start := max(min(at, len(r))-snippetWidth/4, 0)
+	end := min(start+snippetWidth, len(r))
+	out := string(r[start:end])
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(r) {
+		out += "…"
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func testIndex() *Index {
+	return &Index{Documents: []Document{
+		{Entry: "rules/go", Path: "rules/go/basic.mdc", Content: "# Basics\nUse table tests.\n"},
+		{Entry: "rules/testing", Path: "rules/testing/go.mdc", Content: "Write table tests in go.\nKeep them short.\n"},
+		{Entry: "rules/redis", Path: "rules/redis/basic.mdc", Content: "Cache keys expire.\n"},
+		{Entry: "commands/review", Path: "commands/review.md", Content: "Review the go code and its tests.\n"},
+	}}
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		types []string
+		want  []string // paths, best first
+	}{
+		{
+			name:  "name match beats body match",
+			query: "go",
+			want:  []string{"rules/testing/go.mdc", "rules/go/basic.mdc", "commands/review.md"},
+		},
+		{
+			name:  "every word is required",
+			query: "table go",
+			want:  []string{"rules/testing/go.mdc", "rules/go/basic.mdc"},
+		},
+		{
+			name:  "case-insensitive",
+			query: "CACHE Keys",
+			want:  []string{"rules/redis/basic.mdc"},
+		},
+		{
+			name:  "type filter",
+			query: "go tests",
+			types: []string{"commands"},
+			want:  []string{"commands/review.md"},
+		},
+		{
+			name:  "no match",
+			query: "go python",
+		},
+		{
+			name:  "empty query",
+			query: "  ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, m := range testIndex().Search(tt.query, tt.types) {
+				got = append(got, m.Path)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchLines(t *testing.T) {
+	idx := &Index{Documents: []Document{{
+		Entry:   "rules/go",
+		Path:    "rules/go/basic.mdc",
+		Content: "intro\n## Errors\nwrap errors\nnothing here\nreturn errors early\nerrors are values\nlast errors\n",
+	}}}
+	matches := idx.Search("errors", nil)
+	if len(matches) != 1 {
+		t.Fatalf("Search() = %d matches, want 1", len(matches))
+	}
+	var numbers []int
+	for _, l := range matches[0].Lines {
+		numbers = append(numbers, l.Number)
+	}
+	if len(numbers) != maxLines || numbers[0] != 2 || numbers[1] != 3 || numbers[2] != 5 {
+		t.Errorf("matching lines = %v, want the first %d in file order: [2 3 5]", numbers, maxLines)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("x", 200)
+	tests := []struct {
+		name     string
+		line     string
+		term     string
+		wantCut  bool
+		wantTerm bool
+	}{
+		{name: "short line", line: "  use gofmt  ", term: "gofmt", wantTerm: true},
+		{name: "match at the end of a long line", line: long + " needle", term: "needle", wantCut: true, wantTerm: true},
+		{name: "match after multi-byte text", line: strings.Repeat("règles ", 30) + "needle " + long, term: "needle", wantCut: true, wantTerm: true},
+		{name: "match after text that lowercases to fewer bytes", line: strings.Repeat("İ", 400) + " needle " + long, term: "needle", wantCut: true, wantTerm: true},
+		{name: "match in multi-byte text", line: strings.Repeat("日本語", 60) + "検索" + strings.Repeat("日本語", 60), term: "検索", wantCut: true, wantTerm: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := matchingLines(tt.line, []string{tt.term})
+			if len(lines) != 1 {
+				t.Fatalf("matchingLines() = %v, want 1 line", lines)
+			}
+			got := lines[0].Text
+			if !utf8.ValidString(got) {
+				t.Errorf("snippet %q is not valid UTF-8", got)
+			}
+			if n := utf8.RuneCountInString(got); n > snippetWidth+2 {
+				t.Errorf("snippet has %d runes, want at most %d", n, snippetWidth+2)
+			}
+			if strings.Contains(got, "…") != tt.wantCut {
+				t.Errorf("snippet %q cut = %v, want %v", got, !tt.wantCut, tt.wantCut)
+			}
+			if strings.Contains(got, tt.term) != tt.wantTerm {
+				t.Errorf("snippet %q doesn't contain %q", got, tt.term)
+			}
+			if strings.HasPrefix(got, " ") || strings.HasSuffix(got, " ") {
+				t.Errorf("snippet %q is not trimmed", got)
+			}
+		})
+	}
+}
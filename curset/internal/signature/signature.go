@@ -0,0 +1,135 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Signature algorithms used by minisign.
+var (
+	algLegacy = [2]byte{'E', 'd'} // Ed25519 over the message itself
+	algHashed = [2]byte{'E', 'D'} // Ed25519 over the BLAKE2b-512 hash of the message
+)
+
+// PublicKey is a minisign Ed25519 public key.
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// IDString returns the key ID in the hex form minisign prints.
+func (k PublicKey) IDString() string {
+	id := k.ID
+	// minisign shows key IDs as little-endian 64-bit numbers.
+	for i, j := 0, len(id)-1; i < j; i, j = i+1, j-1 {
+		id[i], id[j] = id[j], id[i]
+	}
+	return strings.ToUpper(hex.EncodeToString(id[:]))
+}
+
+// ParsePublicKey parses a minisign public key, either the base64 line ("RWQ...")
+// or the full contents of a minisign.pub file.
+func ParsePublicKey(s string) (PublicKey, error) {
+	line := lastLine(s)
+
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(raw) != 2+8+ed25519.PublicKeySize || !bytes.Equal(raw[:2], algLegacy[:]) {
+		return PublicKey{}, errors.New("invalid public key: not a minisign Ed25519 key")
+	}
+
+	var k PublicKey
+	copy(k.ID[:], raw[2:10])
+	k.Key = ed25519.PublicKey(raw[10:])
+	return k, nil
+}
+
+// Signature is a parsed minisign signature file.
+type Signature struct {
+	Algorithm      [2]byte
+	KeyID          [8]byte
+	Sig            []byte
+	TrustedComment string
+	GlobalSig      []byte
+}
+
+// ParseSignature parses the contents of a minisign .minisig file.
+func ParseSignature(data []byte) (*Signature, error) {
+	lines := strings.Split(strings.TrimSpace(strings.ReplaceAll(string(data), "\r\n", "\n")), "\n")
+	if len(lines) < 4 {
+		return nil, errors.New("invalid signature: expected 4 lines")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	if len(raw) != 2+8+ed25519.SignatureSize {
+		return nil, errors.New("invalid signature: unexpected length")
+	}
+
+	trusted, ok := strings.CutPrefix(lines[2], "trusted comment: ")
+	if !ok {
+		return nil, errors.New("invalid signature: missing trusted comment")
+	}
+
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return nil, errors.New("invalid signature: bad global signature")
+	}
+
+	sig := &Signature{Sig: raw[10:], TrustedComment: trusted, GlobalSig: global}
+	copy(sig.Algorithm[:], raw[:2])
+	copy(sig.KeyID[:], raw[2:10])
+	return sig, nil
+}
+
+// Verify checks a minisign signature of message against the trusted keys and returns
+// the key that signed it. The trusted comment is verified as well.
+func Verify(message, sigData []byte, keys []PublicKey) (PublicKey, error) {
+	sig, err := ParseSignature(sigData)
+	if err != nil {
+		return PublicKey{}, err
+	}
+
+	signed := message
+	switch sig.Algorithm {
+	case algLegacy:
+	case algHashed:
+		sum := blake2b.Sum512(message)
+		signed = sum[:]
+	default:
+		return PublicKey{}, fmt.Errorf("unsupported signature algorithm %q", sig.Algorithm[:])
+	}
+
+	for _, k := range keys {
+		if k.ID != sig.KeyID {
+			continue
+		}
+		if !ed25519.Verify(k.Key, signed, sig.Sig) {
+			return PublicKey{}, fmt.Errorf("signature does not match key %s", k.IDString())
+		}
+		if !ed25519.Verify(k.Key, append(append([]byte{}, sig.Sig...), sig.TrustedComment...), sig.GlobalSig) {
+			return PublicKey{}, fmt.Errorf("trusted comment signature does not match key %s", k.IDString())
+		}
+		return k, nil
+	}
+
+	unknown := PublicKey{ID: sig.KeyID}
+	return PublicKey{}, fmt.Errorf("signed by untrusted key %s", unknown.IDString())
+}
+
+// lastLine returns the last non-empty line of s, trimmed.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
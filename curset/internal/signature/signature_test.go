@@ -0,0 +1,171 @@
+package signature
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// testKey returns a deterministic key pair with the given key ID.
+func testKey(seed byte, id [8]byte) (PublicKey, ed25519.PrivateKey) {
+	priv := ed25519.NewKeyFromSeed(bytesOf(seed, ed25519.SeedSize))
+	return PublicKey{ID: id, Key: priv.Public().(ed25519.PublicKey)}, priv
+}
+
+func bytesOf(b byte, n int) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = b
+	}
+	return out
+}
+
+// encodeKey returns the base64 line of a minisign public key.
+func encodeKey(alg [2]byte, k PublicKey) string {
+	raw := append(append(alg[:], k.ID[:]...), k.Key...)
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// sign returns a minisign signature file of message made with priv under key ID id.
+func sign(alg [2]byte, id [8]byte, priv ed25519.PrivateKey, message []byte, trusted string) []byte {
+	signed := message
+	if alg == algHashed {
+		sum := blake2b.Sum512(message)
+		signed = sum[:]
+	}
+	sig := ed25519.Sign(priv, signed)
+	global := ed25519.Sign(priv, append(append([]byte{}, sig...), trusted...))
+
+	raw := append(append(alg[:], id[:]...), sig...)
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(raw) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+}
+
+func TestParsePublicKey(t *testing.T) {
+	key, _ := testKey(1, [8]byte{1, 2, 3, 4, 5, 6, 7, 8})
+	line := encodeKey(algLegacy, key)
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "base64 line", input: line},
+		{name: "surrounding whitespace", input: "  " + line + "\n\n"},
+		{name: "minisign.pub file", input: "untrusted comment: minisign public key 0807060504030201\n" + line + "\n"},
+		{name: "not base64", input: "not a key!", wantErr: "invalid public key"},
+		{name: "too short", input: base64.StdEncoding.EncodeToString([]byte("Ed1234")), wantErr: "not a minisign Ed25519 key"},
+		{name: "wrong algorithm", input: encodeKey(algHashed, key), wantErr: "not a minisign Ed25519 key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePublicKey(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParsePublicKey() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePublicKey() error = %v", err)
+			}
+			if got.ID != key.ID || !got.Key.Equal(key.Key) {
+				t.Errorf("ParsePublicKey() = %v, want %v", got, key)
+			}
+		})
+	}
+}
+
+func TestIDString(t *testing.T) {
+	k := PublicKey{ID: [8]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0xab}}
+	if got, want := k.IDString(), "AB07060504030201"; got != want {
+		t.Errorf("IDString() = %s, want %s", got, want)
+	}
+}
+
+func TestParseSignature(t *testing.T) {
+	id := [8]byte{1, 2, 3, 4, 5, 6, 7, 8}
+	_, priv := testKey(1, id)
+	valid := string(sign(algHashed, id, priv, []byte("message"), "timestamp:1"))
+	lines := strings.Split(valid, "\n")
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "valid", input: valid},
+		{name: "CRLF line endings", input: strings.ReplaceAll(valid, "\n", "\r\n")},
+		{name: "too few lines", input: strings.Join(lines[:3], "\n"), wantErr: "expected 4 lines"},
+		{name: "signature not base64", input: strings.Join([]string{lines[0], "!!", lines[2], lines[3]}, "\n"), wantErr: "invalid signature"},
+		{name: "signature too short", input: strings.Join([]string{lines[0], "RUQ=", lines[2], lines[3]}, "\n"), wantErr: "unexpected length"},
+		{name: "missing trusted comment", input: strings.Join([]string{lines[0], lines[1], "comment: x", lines[3]}, "\n"), wantErr: "missing trusted comment"},
+		{name: "bad global signature", input: strings.Join([]string{lines[0], lines[1], lines[2], "RUQ="}, "\n"), wantErr: "bad global signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, err := ParseSignature([]byte(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseSignature() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSignature() error = %v", err)
+			}
+			if sig.Algorithm != algHashed || sig.KeyID != id || sig.TrustedComment != "timestamp:1" {
+				t.Errorf("ParseSignature() = %+v", sig)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	id := [8]byte{1, 2, 3, 4, 5, 6, 7, 8}
+	key, priv := testKey(1, id)
+	other, otherPriv := testKey(2, [8]byte{8, 7, 6, 5, 4, 3, 2, 1})
+	message := []byte(`{"collections":{}}`)
+
+	tamperedComment := strings.Replace(string(sign(algHashed, id, priv, message, "timestamp:1")), "timestamp:1", "timestamp:2", 1)
+
+	tests := []struct {
+		name    string
+		message []byte
+		sig     []byte
+		keys    []PublicKey
+		wantErr string
+	}{
+		{name: "hashed", message: message, sig: sign(algHashed, id, priv, message, "t"), keys: []PublicKey{key}},
+		{name: "legacy", message: message, sig: sign(algLegacy, id, priv, message, "t"), keys: []PublicKey{key}},
+		{name: "second trusted key", message: message, sig: sign(algHashed, id, priv, message, "t"), keys: []PublicKey{other, key}},
+		{name: "modified message", message: []byte(`{"collections":{"x":{}}}`), sig: sign(algHashed, id, priv, message, "t"), keys: []PublicKey{key}, wantErr: "signature does not match"},
+		{name: "modified trusted comment", message: message, sig: []byte(tamperedComment), keys: []PublicKey{key}, wantErr: "trusted comment signature does not match"},
+		{name: "untrusted key", message: message, sig: sign(algHashed, other.ID, otherPriv, message, "t"), keys: []PublicKey{key}, wantErr: "untrusted key 0102030405060708"},
+		{name: "key ID reused by another key", message: message, sig: sign(algHashed, id, otherPriv, message, "t"), keys: []PublicKey{key}, wantErr: "signature does not match"},
+		{name: "unsupported algorithm", message: message, sig: sign([2]byte{'X', 'X'}, id, priv, message, "t"), keys: []PublicKey{key}, wantErr: "unsupported signature algorithm"},
+		{name: "no keys", message: message, sig: sign(algHashed, id, priv, message, "t"), wantErr: "untrusted key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Verify(tt.message, tt.sig, tt.keys)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Verify() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if got.ID != key.ID {
+				t.Errorf("Verify() signed by %s, want %s", got.IDString(), key.IDString())
+			}
+		})
+	}
+}
@@ -0,0 +1,478 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+)
+
+const (
+	metaFileName = "snapshot.json" // snapshot metadata inside a snapshot folder
+	filesDir     = "files"         // copy of the saved paths inside a snapshot folder
+)
+
+// Snapshot is a saved copy of the part of a .cursor/ folder that curset manages: the
+// manifest, the installed entries and the backups made with --backup.
+type Snapshot struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Dir     string    `json:"dir"`             // absolute path of the .cursor/ folder
+	Command string    `json:"command"`         // the curset command that was about to change the folder
+	Paths   []string  `json:"paths,omitempty"` // saved paths, relative to the .cursor/ folder
+	Files   int       `json:"files"`
+	Size    int64     `json:"size"` // total size of the files in bytes
+}
+
+// Store holds the snapshots of one .cursor/ folder, in a subfolder of the state directory
+// named after a hash of the folder's absolute path.
+type Store struct {
+	dir       string // the .cursor/ folder
+	root      string // where its snapshots are kept
+	cursorAbs string
+}
+
+// Open returns the snapshot store of cursorDir inside stateDir.
+func Open(stateDir, cursorDir string) (*Store, error) {
+	abs, err := filepath.Abs(cursorDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", cursorDir, err)
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return &Store{
+		dir:       cursorDir,
+		root:      filepath.Join(stateDir, "snapshots", hex.EncodeToString(sum[:])[:16]),
+		cursorAbs: abs,
+	}, nil
+}
+
+// Take saves the manifest, the installed entries and the backups of the .cursor/ folder in
+// a new snapshot. Content curset doesn't manage, such as Cursor's own settings, is left out.
+func (s *Store) Take(command string) (*Snapshot, error) {
+	now := time.Now()
+	id := now.Format("20060102-150405")
+	for n := 2; ; n++ {
+		if _, err := os.Stat(filepath.Join(s.root, id)); os.IsNotExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", now.Format("20060102-150405"), n)
+	}
+
+	m, err := manifest.Load(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(s.root, id)
+	snap := &Snapshot{ID: id, Time: now.UTC(), Dir: s.cursorAbs, Command: command}
+	if err := os.MkdirAll(filepath.Join(path, filesDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	snap.Paths, snap.Files, snap.Size, err = copyPaths(s.dir, filepath.Join(path, filesDir), managedPaths(m))
+	if err != nil {
+		os.RemoveAll(path)
+		return nil, fmt.Errorf("failed to snapshot %s: %w", s.dir, err)
+	}
+
+	if err := s.save(snap); err != nil {
+		os.RemoveAll(path)
+		return nil, err
+	}
+	return snap, nil
+}
+
+// Add saves more paths (relative to the .cursor/ folder) in an existing snapshot, e.g.
+// unmanaged content that is about to be overwritten with --force.
+func (s *Store) Add(id string, paths []string) error {
+	snap, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	added, files, size, err := copyPaths(s.dir, filepath.Join(s.root, id, filesDir), paths)
+	if err != nil {
+		return fmt.Errorf("failed to add to snapshot '%s': %w", id, err)
+	}
+	snap.Paths = append(snap.Paths, added...)
+	snap.Files += files
+	snap.Size += size
+	return s.save(snap)
+}
+
+// save writes the metadata of snap.
+func (s *Store) save(snap *Snapshot) error {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.root, snap.ID, metaFileName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// managedPaths returns the paths of the .cursor/ folder that m accounts for: the manifest,
+// the backups and the installed entries, relative to the folder.
+func managedPaths(m *manifest.Manifest) []string {
+	return append([]string{manifest.FileName, manifest.BackupDir}, m.Paths()...)
+}
+
+// List returns the snapshots, oldest first.
+func (s *Store) List() ([]*Snapshot, error) {
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	var snaps []*Snapshot
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		snap, err := s.Get(e.Name())
+		if err != nil {
+			continue // incomplete snapshot, e.g. from an interrupted run
+		}
+		snaps = append(snaps, snap)
+	}
+
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].Time.Before(snaps[j].Time) })
+	return snaps, nil
+}
+
+// Get returns the snapshot with the given ID.
+func (s *Store) Get(id string) (*Snapshot, error) {
+	if id == "" || id != filepath.Base(id) || id == "." || id == ".." {
+		return nil, fmt.Errorf("invalid snapshot ID '%s'", id)
+	}
+	data, err := os.ReadFile(filepath.Join(s.root, id, metaFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("snapshot '%s' not found for %s", id, s.dir)
+		}
+		return nil, fmt.Errorf("failed to read snapshot '%s': %w", id, err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot '%s': %w", id, err)
+	}
+	return &snap, nil
+}
+
+// Restore brings the curset-managed part of the .cursor/ folder back to the snapshot: the
+// manifest and installed entries are replaced with the saved ones, so entries installed since
+// are removed. Backups are merged rather than replaced, and content that an entry installed
+// since was installed over with --backup or --force is put back. Everything else in the
+// folder is left alone: if a saved path now holds content curset doesn't manage, nothing is
+// restored. The caller must hold the folder's lock.
+func (s *Store) Restore(id string) error {
+	snap, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	files := filepath.Join(s.root, id, filesDir)
+
+	current, err := manifest.Load(s.dir)
+	if err != nil {
+		return err
+	}
+	saved, err := manifest.Load(files)
+	if err != nil {
+		return fmt.Errorf("failed to read the manifest of snapshot '%s': %w", id, err)
+	}
+	paths := snap.Paths
+	if paths == nil {
+		// Snapshots taken before the saved paths were recorded hold the whole folder.
+		paths = managedPaths(saved)
+	}
+
+	// Check everything before changing anything: the paths come from manifests and snapshot
+	// metadata, and a saved path that now holds content curset doesn't manage, e.g. a folder
+	// recreated by hand after its entry was uninstalled, must not be replaced.
+	managed := make(map[string]bool)
+	for _, p := range managedPaths(current) {
+		managed[p] = true
+	}
+	for _, e := range current.Entries {
+		if e.Conflict != nil {
+			for _, p := range e.Conflict.Paths {
+				if err := checkInside(p); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	var unmanaged []string
+	for _, p := range append(managedPaths(current), paths...) {
+		if err := checkInside(p); err != nil {
+			return err
+		}
+		if managed[p] {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(s.dir, p)); err == nil {
+			unmanaged = append(unmanaged, p)
+		}
+	}
+	if len(unmanaged) > 0 {
+		return fmt.Errorf("cannot restore snapshot '%s' over content curset doesn't manage: %s (move it out of the way first)", id, strings.Join(unmanaged, ", "))
+	}
+
+	for _, p := range append(managedPaths(current), paths...) {
+		if p == manifest.BackupDir {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(s.dir, p)); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", filepath.Join(s.dir, p), err)
+		}
+	}
+	if _, _, _, err := copyPaths(files, s.dir, paths); err != nil {
+		return fmt.Errorf("failed to restore snapshot '%s': %w", id, err)
+	}
+
+	// Entries installed since the snapshot are gone; bring back what they were installed over.
+	for _, e := range current.Entries {
+		if e.Conflict == nil || sameConflict(saved.GetEntry(e.Type, e.Name), e.Conflict) {
+			continue
+		}
+		if err := s.putBack(e.Conflict); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameConflict reports whether e was installed over the same content as c.
+func sameConflict(e *manifest.Entry, c *manifest.Conflict) bool {
+	return e != nil && e.Conflict != nil && e.Conflict.Mode == c.Mode &&
+		e.Conflict.Backup == c.Backup && e.Conflict.Snapshot == c.Snapshot
+}
+
+// putBack moves the content an entry was installed over back into place, from the backup
+// folder or the snapshot holding it. Paths that are in use again are left as they are.
+func (s *Store) putBack(c *manifest.Conflict) error {
+	for _, p := range c.Paths {
+		to := filepath.Join(s.dir, p)
+		if _, err := os.Lstat(to); err == nil {
+			continue
+		}
+		switch c.Mode {
+		case manifest.ConflictBackup:
+			from := filepath.Join(s.dir, c.Backup, p)
+			if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+				return err
+			}
+			if err := os.Rename(from, to); err != nil {
+				return fmt.Errorf("failed to restore %s from its backup: %w", to, err)
+			}
+		case manifest.ConflictForce:
+			if err := s.RestorePaths(c.Snapshot, []string{p}); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", to, err)
+			}
+		}
+	}
+	return nil
+}
+
+// RestorePaths copies the given paths (relative to the .cursor/ folder) back from the
+// snapshot, leaving the rest of the folder as it is.
+func (s *Store) RestorePaths(id string, paths []string) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if err := checkInside(p); err != nil {
+			return err
+		}
+		from, to := filepath.Join(s.root, id, filesDir, p), filepath.Join(s.dir, p)
+		if _, err := os.Lstat(from); err != nil {
+			return fmt.Errorf("%s is not in snapshot '%s'", p, id)
+		}
+		if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+			return err
+		}
+		if _, _, err := copyTree(from, to); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// Drop deletes the snapshot with the given ID. A snapshot holding content that a managed
+// entry was installed over with --force is kept, since uninstalling the entry restores it.
+func (s *Store) Drop(id string) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	inUse, err := s.InUse()
+	if err != nil {
+		return err
+	}
+	if refs := inUse[id]; len(refs) > 0 {
+		return fmt.Errorf("snapshot '%s' is in use: it holds the content overwritten with --force by %s, which uninstalling restores", id, strings.Join(refs, ", "))
+	}
+	return s.remove(id)
+}
+
+// InUse returns the entries ("type/name") of the folder's manifest installed with --force
+// over content kept in a snapshot, by snapshot ID.
+func (s *Store) InUse() (map[string][]string, error) {
+	m, err := manifest.Load(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	return m.ForcedSnapshots(), nil
+}
+
+// Prune drops the oldest snapshots so that at most keep remain, not counting the snapshots
+// in use (see InUse), which are never dropped.
+func (s *Store) Prune(keep int) error {
+	snaps, err := s.List()
+	if err != nil {
+		return err
+	}
+	inUse, err := s.InUse()
+	if err != nil {
+		return err
+	}
+	var unused []*Snapshot
+	for _, snap := range snaps {
+		if len(inUse[snap.ID]) == 0 {
+			unused = append(unused, snap)
+		}
+	}
+	for len(unused) > keep {
+		if err := s.remove(unused[0].ID); err != nil {
+			return err
+		}
+		unused = unused[1:]
+	}
+	return nil
+}
+
+// remove deletes the folder of the snapshot with the given ID.
+func (s *Store) remove(id string) error {
+	if err := os.RemoveAll(filepath.Join(s.root, id)); err != nil {
+		return fmt.Errorf("failed to drop snapshot '%s': %w", id, err)
+	}
+	return nil
+}
+
+// copyPaths copies the given paths (relative to src) that exist from src to dst.
+// Returns the paths copied, the number of files and their total size.
+func copyPaths(src, dst string, paths []string) ([]string, int, int64, error) {
+	var copied []string
+	files := 0
+	var size int64
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		if err := checkInside(p); err != nil {
+			return nil, 0, 0, err
+		}
+		if _, err := os.Lstat(filepath.Join(src, p)); os.IsNotExist(err) {
+			continue
+		}
+		to := filepath.Join(dst, p)
+		if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+			return nil, 0, 0, err
+		}
+		n, sz, err := copyTree(filepath.Join(src, p), to)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		copied = append(copied, p)
+		files += n
+		size += sz
+	}
+	return copied, files, size, nil
+}
+
+// checkInside returns an error if p, a path relative to the .cursor/ folder read from a
+// manifest or snapshot, doesn't resolve to something inside the folder.
+func checkInside(p string) error {
+	clean := filepath.Clean(p)
+	if p == "" || filepath.IsAbs(p) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("refusing %s: outside the .cursor/ folder", p)
+	}
+	return nil
+}
+
+// copyTree copies src, a folder or a single file, to dst, keeping symlinks as symlinks and
+// skipping the lock and history files. Returns the number of files copied and their total size.
+func copyTree(src, dst string) (int, int64, error) {
+	files := 0
+	var size int64
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.Name() == manifest.LockFileName || d.Name() == manifest.TakeoverFileName || d.Name() == manifest.HistoryFileName {
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			files++
+			os.Remove(target) // replaced when merging into an existing folder
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			n, err := copyFile(path, target)
+			if err != nil {
+				return err
+			}
+			files++
+			size += n
+		}
+		return nil
+	})
+	return files, size, err
+}
+
+// copyFile copies the regular file src to dst with the same permissions.
+func copyFile(src, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return 0, err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
@@ -0,0 +1,341 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/manifest"
+)
+
+// write creates the file path (relative to dir) with content.
+func write(t *testing.T, dir, path, content string) {
+	t.Helper()
+	full := filepath.Join(dir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// read returns the content of the file path (relative to dir), or "" if it doesn't exist.
+func read(t *testing.T, dir, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+	if os.IsNotExist(err) {
+		return ""
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// track records entries in the manifest of dir.
+func track(t *testing.T, dir string, entries ...manifest.Entry) {
+	t.Helper()
+	m, err := manifest.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		m.AddOrUpdate(e)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newStore returns a store for a new .cursor/ folder holding the managed entries rules/go
+// and commands/review, and some content curset doesn't manage.
+func newStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), ".cursor")
+	write(t, dir, "rules/go/basic.mdc", "go")
+	write(t, dir, "commands/review.md", "review")
+	write(t, dir, "rules/mine.mdc", "mine")
+	write(t, dir, "extensions/big.vsix", "extension")
+	track(t, dir,
+		manifest.Entry{Type: "rules", Name: "go", IsDir: true, Files: []string{"rules/go/basic.mdc"}},
+		manifest.Entry{Type: "commands", Name: "review", Files: []string{"commands/review.md"}},
+	)
+
+	store, err := Open(t.TempDir(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, dir
+}
+
+// snapshotFiles returns the files saved in a snapshot, relative to the .cursor/ folder.
+func snapshotFiles(t *testing.T, s *Store, id string) []string {
+	t.Helper()
+	root := filepath.Join(s.root, id, filesDir)
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestTake(t *testing.T) {
+	store, dir := newStore(t)
+	write(t, dir, manifest.BackupDir+"/20250101-120000/rules/python/old.mdc", "old")
+	write(t, dir, manifest.LockFileName, "pid 1\n")
+	write(t, dir, manifest.TakeoverFileName, "")
+	write(t, dir, manifest.HistoryFileName, "{}\n")
+
+	snap, err := store.Take("curset install go")
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+
+	want := []string{
+		manifest.FileName,
+		manifest.BackupDir + "/20250101-120000/rules/python/old.mdc",
+		"commands/review.md",
+		"rules/go/basic.mdc",
+	}
+	sort.Strings(want)
+	if got := snapshotFiles(t, store, snap.ID); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("snapshot holds %v, want %v", got, want)
+	}
+	if snap.Files != len(want) {
+		t.Errorf("Files = %d, want %d", snap.Files, len(want))
+	}
+}
+
+func TestTakeEmptyFolder(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".cursor")
+	store, err := Open(t.TempDir(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := store.Take("curset install go")
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if snap.Files != 0 {
+		t.Errorf("Files = %d, want 0", snap.Files)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Take() created %s", dir)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	store, dir := newStore(t)
+	snap, err := store.Take("curset install python")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes after the snapshot: an updated entry, a new entry, a removed entry and new
+	// content curset doesn't manage.
+	write(t, dir, "rules/go/basic.mdc", "go v2")
+	write(t, dir, "rules/go/extra.mdc", "extra")
+	write(t, dir, "rules/python/basic.mdc", "python")
+	os.Remove(filepath.Join(dir, "commands", "review.md"))
+	write(t, dir, "rules/notes.mdc", "notes")
+	write(t, dir, manifest.LockFileName, "pid 1\n")
+	m, _ := manifest.Load(dir)
+	m.RemoveEntry("commands", "review")
+	m.Save()
+	track(t, dir, manifest.Entry{Type: "rules", Name: "python", IsDir: true, Files: []string{"rules/python/basic.mdc"}})
+
+	if err := store.Restore(snap.ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	for path, want := range map[string]string{
+		"rules/go/basic.mdc":     "go",
+		"rules/go/extra.mdc":     "",
+		"rules/python/basic.mdc": "",
+		"commands/review.md":     "review",
+		"rules/mine.mdc":         "mine",
+		"rules/notes.mdc":        "notes",
+		"extensions/big.vsix":    "extension",
+		manifest.LockFileName:    "pid 1\n",
+	} {
+		if got := read(t, dir, path); got != want {
+			t.Errorf("%s = %q after Restore, want %q", path, got, want)
+		}
+	}
+
+	m, err = manifest.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(m.Paths(), ","); got != "commands/review.md,rules/go" {
+		t.Errorf("manifest tracks %s after Restore, want commands/review.md,rules/go", got)
+	}
+}
+
+func TestRestorePutsBackConflicts(t *testing.T) {
+	t.Run("backup", func(t *testing.T) {
+		store, dir := newStore(t)
+		write(t, dir, "rules/python/mine.mdc", "mine")
+		snap, err := store.Take("curset install python --backup")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Install rules/python over the unmanaged folder with --backup.
+		backup := filepath.Join(manifest.BackupDir, "20250101-120000")
+		os.MkdirAll(filepath.Join(dir, backup, "rules"), 0755)
+		if err := os.Rename(filepath.Join(dir, "rules", "python"), filepath.Join(dir, backup, "rules", "python")); err != nil {
+			t.Fatal(err)
+		}
+		write(t, dir, "rules/python/basic.mdc", "python")
+		track(t, dir, manifest.Entry{
+			Type: "rules", Name: "python", IsDir: true, Files: []string{"rules/python/basic.mdc"},
+			Conflict: &manifest.Conflict{Mode: manifest.ConflictBackup, Paths: []string{"rules/python"}, Backup: backup},
+		})
+
+		if err := store.Restore(snap.ID); err != nil {
+			t.Fatalf("Restore() error = %v", err)
+		}
+		if got := read(t, dir, "rules/python/mine.mdc"); got != "mine" {
+			t.Errorf("rules/python/mine.mdc = %q after Restore, want it moved back from the backup", got)
+		}
+		if got := read(t, dir, "rules/python/basic.mdc"); got != "" {
+			t.Errorf("rules/python/basic.mdc = %q after Restore, want it removed", got)
+		}
+	})
+
+	t.Run("force", func(t *testing.T) {
+		store, dir := newStore(t)
+		write(t, dir, "rules/python/mine.mdc", "mine")
+		before, err := store.Take("curset add rules/go")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Install rules/python over the unmanaged folder with --force.
+		snap, err := store.Take("curset install python --force")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Add(snap.ID, []string{"rules/python"}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+		os.RemoveAll(filepath.Join(dir, "rules", "python"))
+		write(t, dir, "rules/python/basic.mdc", "python")
+		track(t, dir, manifest.Entry{
+			Type: "rules", Name: "python", IsDir: true, Files: []string{"rules/python/basic.mdc"},
+			Conflict: &manifest.Conflict{Mode: manifest.ConflictForce, Paths: []string{"rules/python"}, Snapshot: snap.ID},
+		})
+
+		// The snapshot taken before the install holds the overwritten folder.
+		if err := store.Restore(snap.ID); err != nil {
+			t.Fatalf("Restore() error = %v", err)
+		}
+		if got := read(t, dir, "rules/python/mine.mdc"); got != "mine" {
+			t.Errorf("rules/python/mine.mdc = %q after Restore, want it restored", got)
+		}
+
+		// An older snapshot doesn't hold it, but the entry recorded where it is kept.
+		os.RemoveAll(filepath.Join(dir, "rules", "python"))
+		write(t, dir, "rules/python/basic.mdc", "python")
+		track(t, dir, manifest.Entry{
+			Type: "rules", Name: "python", IsDir: true, Files: []string{"rules/python/basic.mdc"},
+			Conflict: &manifest.Conflict{Mode: manifest.ConflictForce, Paths: []string{"rules/python"}, Snapshot: snap.ID},
+		})
+		if err := store.Restore(before.ID); err != nil {
+			t.Fatalf("Restore() error = %v", err)
+		}
+		if got := read(t, dir, "rules/python/mine.mdc"); got != "mine" {
+			t.Errorf("rules/python/mine.mdc = %q after restoring an older snapshot, want it restored", got)
+		}
+		if got := read(t, dir, "rules/python/basic.mdc"); got != "" {
+			t.Errorf("rules/python/basic.mdc = %q after Restore, want it removed", got)
+		}
+	})
+}
+
+func TestCopyTreeSkipsLockFiles(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	for _, name := range []string{manifest.LockFileName, manifest.TakeoverFileName, manifest.HistoryFileName, manifest.FileName} {
+		write(t, src, name, "x")
+	}
+
+	if _, _, err := copyTree(src, dst); err != nil {
+		t.Fatalf("copyTree() error = %v", err)
+	}
+	for name, want := range map[string]string{
+		manifest.LockFileName:     "",
+		manifest.TakeoverFileName: "",
+		manifest.HistoryFileName:  "",
+		manifest.FileName:         "x",
+	} {
+		if got := read(t, dst, name); got != want {
+			t.Errorf("%s = %q after copyTree, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRestoreLeavesUnmanagedContent(t *testing.T) {
+	store, dir := newStore(t)
+	snap, err := store.Take("curset uninstall go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rules/go is uninstalled, then recreated by hand.
+	os.RemoveAll(filepath.Join(dir, "rules", "go"))
+	m, _ := manifest.Load(dir)
+	m.RemoveEntry("rules", "go")
+	m.Save()
+	write(t, dir, "rules/go/own.mdc", "own")
+
+	err = store.Restore(snap.ID)
+	if err == nil || !strings.Contains(err.Error(), "rules/go") {
+		t.Fatalf("Restore() error = %v, want it to refuse replacing rules/go", err)
+	}
+	if got := read(t, dir, "rules/go/own.mdc"); got != "own" {
+		t.Errorf("rules/go/own.mdc = %q after Restore, want it kept", got)
+	}
+	if m, _ := manifest.Load(dir); m.IsManaged("rules", "go") {
+		t.Errorf("rules/go is managed after a refused Restore")
+	}
+}
+
+func TestRestoreRejectsPathsOutside(t *testing.T) {
+	for _, path := range []string{"../outside", "rules/../../outside", "/tmp/outside", "."} {
+		t.Run(path, func(t *testing.T) {
+			store, dir := newStore(t)
+			write(t, filepath.Dir(dir), "outside/keep.txt", "keep")
+			snap, err := store.Take("curset install go")
+			if err != nil {
+				t.Fatal(err)
+			}
+			snap.Paths = append(snap.Paths, path)
+			if err := store.save(snap); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := store.Restore(snap.ID); err == nil || !strings.Contains(err.Error(), "outside the .cursor/ folder") {
+				t.Fatalf("Restore() error = %v, want it to refuse %s", err, path)
+			}
+			if got := read(t, filepath.Dir(dir), "outside/keep.txt"); got != "keep" {
+				t.Errorf("outside/keep.txt = %q after Restore, want it kept", got)
+			}
+			if got := read(t, dir, "rules/mine.mdc"); got != "mine" {
+				t.Errorf("rules/mine.mdc = %q after Restore, want it kept", got)
+			}
+		})
+	}
+}
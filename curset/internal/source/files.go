@@ -0,0 +1,100 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/github"
+)
+
+// ErrNotFound is returned by RemoteFiles when the source has no such entry.
+var ErrNotFound = errors.New("not found in remote repository")
+
+// RemoteFiles lists the files of the entry objType/name in src by their path relative to
+// .cursor/, and reports whether the entry is a directory.
+func RemoteFiles(src Source, objType, name string) (map[string]github.ContentEntry, bool, error) {
+	files := make(map[string]github.ContentEntry)
+
+	result, err := src.ListContents(objType + "/" + name)
+	if err != nil {
+		// Not a directory or exact file name: match files by name without extension.
+		parent, err := src.ListContents(objType)
+		if errors.Is(err, github.ErrPathNotFound) {
+			return nil, false, fmt.Errorf("entry %s/%s %w", objType, name, ErrNotFound)
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to list %s: %w", objType, err)
+		}
+		for _, c := range parent.Entries {
+			if c.Type == "dir" || strings.TrimSuffix(c.Name, filepath.Ext(c.Name)) != name {
+				continue
+			}
+			if err := CheckContent(c); err != nil {
+				return nil, false, err
+			}
+			files[filepath.Join(objType, c.Name)] = c
+		}
+		if len(files) == 0 {
+			return nil, false, fmt.Errorf("entry %s/%s %w", objType, name, ErrNotFound)
+		}
+		return files, false, nil
+	}
+
+	if !result.IsDir {
+		c := result.Entries[0]
+		if err := CheckContent(c); err != nil {
+			return nil, false, err
+		}
+		files[filepath.Join(objType, c.Name)] = c
+		return files, false, nil
+	}
+
+	if err := listTree(src, filepath.Join(objType, name), result.Entries, files); err != nil {
+		return nil, false, err
+	}
+	return files, true, nil
+}
+
+// listTree adds the files of a remote directory listing to files, descending into subdirectories.
+func listTree(src Source, dir string, contents []github.ContentEntry, files map[string]github.ContentEntry) error {
+	for _, c := range contents {
+		if err := CheckContent(c); err != nil {
+			return err
+		}
+		rel := filepath.Join(dir, c.Name)
+		switch c.Type {
+		case "dir":
+			result, err := src.ListContents(filepath.ToSlash(rel))
+			if err != nil {
+				return err
+			}
+			if err := listTree(src, rel, result.Entries, files); err != nil {
+				return err
+			}
+		case "file":
+			files[rel] = c
+		}
+	}
+	return nil
+}
+
+// CheckContent refuses listing entries whose name could escape the .cursor/ folder, that
+// are not regular files or directories (symlinks, submodules), or that are too large.
+func CheckContent(c github.ContentEntry) error {
+	if err := collection.ValidateName(c.Name); err != nil {
+		return fmt.Errorf("refusing %s: unsafe file name: %w", c.Path, err)
+	}
+	switch c.Type {
+	case "file":
+		if c.Size > github.MaxFileSize {
+			return fmt.Errorf("refusing %s: %d bytes exceeds the %d byte limit", c.Path, c.Size, github.MaxFileSize)
+		}
+	case "dir":
+	default:
+		return fmt.Errorf("refusing %s: %s entries are not supported, only files and directories", c.Path, c.Type)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package source
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/github"
+)
+
+func TestCheckContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   github.ContentEntry
+		wantErr string
+	}{
+		{name: "file", entry: github.ContentEntry{Name: "go.mdc", Path: "data/.cursor/rules/go.mdc", Type: "file", Size: 100}},
+		{name: "dir", entry: github.ContentEntry{Name: "go", Path: "data/.cursor/rules/go", Type: "dir"}},
+		{name: "file at the size limit", entry: github.ContentEntry{Name: "big.md", Type: "file", Size: github.MaxFileSize}},
+		{name: "file over the size limit", entry: github.ContentEntry{Name: "big.md", Type: "file", Size: github.MaxFileSize + 1}, wantErr: "exceeds"},
+		{name: "parent dir name", entry: github.ContentEntry{Name: "..", Type: "dir"}, wantErr: "unsafe file name"},
+		{name: "name with separator", entry: github.ContentEntry{Name: "../../.bashrc", Type: "file"}, wantErr: "unsafe file name"},
+		{name: "empty name", entry: github.ContentEntry{Name: "", Type: "file"}, wantErr: "unsafe file name"},
+		{name: "symlink", entry: github.ContentEntry{Name: "link", Type: "symlink"}, wantErr: "symlink entries are not supported"},
+		{name: "submodule", entry: github.ContentEntry{Name: "sub", Type: "submodule"}, wantErr: "submodule entries are not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckContent(tt.entry)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckContent() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CheckContent() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRemoteFiles(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		t.Helper()
+		full := filepath.Join(root, "data", ".cursor", filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("rules/go/basic.mdc", "basic")
+	write("rules/go/testing/table.mdc", "table")
+	write("commands/review.md", "review")
+	write("commands/review.json", "{}")
+	write("commands/other.md", "other")
+	write("rules/linked/real.mdc", "real")
+	if err := os.Symlink("/etc/passwd", filepath.Join(root, "data", ".cursor", "rules", "linked", "passwd.mdc")); err != nil {
+		t.Fatal(err)
+	}
+	src := NewLocal(root)
+
+	tests := []struct {
+		name      string
+		objType   string
+		entry     string
+		wantFiles []string
+		wantDir   bool
+		wantErr   string
+	}{
+		{name: "directory", objType: "rules", entry: "go", wantFiles: []string{"rules/go/basic.mdc", "rules/go/testing/table.mdc"}, wantDir: true},
+		{name: "files by name", objType: "commands", entry: "review", wantFiles: []string{"commands/review.json", "commands/review.md"}},
+		{name: "exact file name", objType: "commands", entry: "other.md", wantFiles: []string{"commands/other.md"}},
+		{name: "missing", objType: "commands", entry: "nope", wantErr: "not found"},
+		{name: "symlink inside", objType: "rules", entry: "linked", wantErr: "symlink entries are not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, isDir, err := RemoteFiles(src, tt.objType, tt.entry)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RemoteFiles() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RemoteFiles() error = %v", err)
+			}
+			var got []string
+			for p := range files {
+				got = append(got, filepath.ToSlash(p))
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.wantFiles, ",") || isDir != tt.wantDir {
+				t.Errorf("RemoteFiles() = %v, %v, want %v, %v", got, isDir, tt.wantFiles, tt.wantDir)
+			}
+		})
+	}
+
+	if _, _, err := RemoteFiles(src, "commands", "nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RemoteFiles() of a missing entry = %v, want ErrNotFound", err)
+	}
+}
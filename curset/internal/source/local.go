@@ -0,0 +1,141 @@
+package source
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/github"
+)
+
+// Local is a cursor-config checkout on disk.
+type Local struct {
+	root string // absolute path of the checkout
+}
+
+// NewLocal creates a source for the checkout at root.
+// A leading "~" is expanded to the home directory.
+func NewLocal(root string) *Local {
+	if root == "~" || strings.HasPrefix(root, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			root = filepath.Join(home, strings.TrimPrefix(root, "~"))
+		}
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &Local{root: root}
+}
+
+// Repo returns the absolute path of the checkout.
+func (l *Local) Repo() string {
+	return l.root
+}
+
+// Ref returns an empty string; local checkouts are used as they are.
+func (l *Local) Ref() string {
+	return ""
+}
+
+// URL returns the file:// URL of the checkout.
+func (l *Local) URL() string {
+	return "file://" + filepath.ToSlash(l.root)
+}
+
+// ResolveRef returns the commit checked out in a git checkout.
+func (l *Local) ResolveRef() (string, error) {
+	out, err := exec.Command("git", "-C", l.root, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD of %s: %w", l.root, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Path returns the absolute path of a file or directory under data/.cursor/.
+func (l *Local) Path(path string) string {
+	return filepath.Join(l.root, "data", ".cursor", filepath.FromSlash(path))
+}
+
+// FetchCollectionJSON reads data/collection.json from the checkout.
+func (l *Local) FetchCollectionJSON() ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(l.root, "data", "collection.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collection.json: %w", err)
+	}
+	return data, nil
+}
+
+// FetchCollectionSignature reads data/collection.json.minisig from the checkout.
+func (l *Local) FetchCollectionSignature() ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(l.root, "data", "collection.json.minisig"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("collection.json is not signed (no collection.json.minisig in %s)", l.root)
+		}
+		return nil, fmt.Errorf("failed to read collection.json.minisig: %w", err)
+	}
+	return data, nil
+}
+
+// ListContents lists a path under data/.cursor/ in the same shape as the GitHub Contents API.
+func (l *Local) ListContents(path string) (*github.ContentsResult, error) {
+	full := l.Path(path)
+
+	info, err := os.Lstat(full)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", github.ErrPathNotFound, path)
+		}
+		return nil, fmt.Errorf("failed to list contents at %s: %w", path, err)
+	}
+
+	if !info.IsDir() {
+		return &github.ContentsResult{Entries: []github.ContentEntry{contentEntry(path, info)}}, nil
+	}
+
+	children, err := os.ReadDir(full)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contents at %s: %w", path, err)
+	}
+
+	entries := make([]github.ContentEntry, 0, len(children))
+	for _, child := range children {
+		info, err := child.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list contents at %s: %w", path, err)
+		}
+		entries = append(entries, contentEntry(path+"/"+child.Name(), info))
+	}
+	return &github.ContentsResult{Entries: entries, IsDir: true}, nil
+}
+
+// DownloadFile reads a file under data/.cursor/.
+func (l *Local) DownloadFile(filePath string) ([]byte, error) {
+	data, err := os.ReadFile(l.Path(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	return data, nil
+}
+
+// contentEntry describes a local file the way the GitHub Contents API would.
+func contentEntry(path string, info os.FileInfo) github.ContentEntry {
+	typ := "file"
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		typ = "symlink"
+	case info.IsDir():
+		typ = "dir"
+	}
+	c := github.ContentEntry{
+		Name: info.Name(),
+		Path: "data/.cursor/" + path,
+		Type: typ,
+	}
+	if typ == "file" {
+		c.Size = info.Size()
+	}
+	return c
+}
@@ -0,0 +1,89 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/bilgehannal/cursor-config/curset/internal/collection"
+	"github.com/bilgehannal/cursor-config/curset/internal/github"
+)
+
+// Source is a repository with the cursor-config layout (data/collection.json and
+// data/.cursor/) that collections and entries are read from.
+// It is either a GitHub repository or a local checkout.
+type Source interface {
+	// FetchCollectionJSON returns the raw data/collection.json.
+	FetchCollectionJSON() ([]byte, error)
+	// FetchCollectionSignature returns the detached minisign signature of collection.json.
+	FetchCollectionSignature() ([]byte, error)
+	// ListContents lists a path under data/.cursor/, e.g. "rules/common".
+	ListContents(path string) (*github.ContentsResult, error)
+	// DownloadFile returns a file under data/.cursor/, e.g. "rules/common/clean-code.mdc".
+	DownloadFile(filePath string) ([]byte, error)
+	// Repo returns the GitHub "owner/repo" or the absolute path of a local checkout.
+	Repo() string
+	// Ref returns the branch, tag or commit; empty for local checkouts.
+	Ref() string
+	// URL returns the web URL of a GitHub repository or the file:// URL of a local checkout.
+	URL() string
+	// ResolveRef returns the commit SHA the source currently points to.
+	ResolveRef() (string, error)
+}
+
+// Open returns the source for repo, which is either a GitHub "owner/repo" or a path to a
+// local checkout (see IsLocal). Empty values fall back to the default repository and ref.
+// The ref is ignored for local checkouts; CheckRef reports that as an error.
+func Open(repo, ref string) Source {
+	if IsLocal(repo) {
+		return NewLocal(repo)
+	}
+	return github.NewClientFor(repo, ref)
+}
+
+// IsLocal reports whether repo is a local path rather than a GitHub "owner/repo".
+// Local paths are absolute or start with ".", "~".
+func IsLocal(repo string) bool {
+	if repo == "" {
+		return false
+	}
+	return repo[0] == '/' || repo[0] == '.' || repo[0] == '~'
+}
+
+// CheckRef returns an error if a ref is given for a local checkout. Local checkouts are
+// always read from their working tree, so the ref would silently be ignored.
+func CheckRef(repo, ref string) error {
+	if IsLocal(repo) && ref != "" {
+		return fmt.Errorf("ref '%s' can't be used with the local checkout %s, which is read from its working tree; check the ref out there instead", ref, repo)
+	}
+	return nil
+}
+
+// ForEntry returns the source a collection entry is fetched from: def, unless the entry
+// overrides the source or ref. An overridden source without a ref uses the default ref.
+// Entries come from a downloaded collection.json, so an overridden source must be a GitHub
+// "owner/repo"; only def may be a local checkout. It returns an error if the entry pins a ref
+// of a local checkout (see CheckRef).
+func ForEntry(def Source, e collection.Entry) (Source, error) {
+	if !e.HasOverride() {
+		return def, nil
+	}
+
+	repo := e.Source
+	if repo == "" {
+		repo = def.Repo()
+	} else if err := collection.ValidateRepo(repo); err != nil {
+		return nil, fmt.Errorf("entry %s: %w", e.Name, err)
+	}
+	if e.Ref != "" {
+		if err := collection.ValidateRef(e.Ref); err != nil {
+			return nil, fmt.Errorf("entry %s: %w", e.Name, err)
+		}
+	}
+	if err := CheckRef(repo, e.Ref); err != nil {
+		return nil, err
+	}
+	ref := e.Ref
+	if ref == "" {
+		ref = github.DefaultRef
+	}
+	return Open(repo, ref), nil
+}